@@ -0,0 +1,39 @@
+// Package cache stores JSON-encoded values in Redis.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/utils"
+)
+
+// GetJSON decodes the value cached under key into out and reports whether
+// there was one. A missing key and an unavailable Redis are both a miss, so
+// callers fall back to the database; the error is only set when the cached
+// value cannot be decoded, in which case the entry is dropped.
+func GetJSON[T any](ctx context.Context, key string, out *T) (bool, error) {
+	data, hit := utils.CacheGet(ctx, key)
+	if !hit {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(data), out); err != nil {
+		global.Logger.Warn("Dropping undecodable cache entry", "key", key, "error", err)
+		utils.CacheDel(ctx, key)
+		return false, err
+	}
+	return true, nil
+}
+
+// SetJSON caches v under key for ttl; zero keeps it until deleted. Only an
+// encoding error is returned; Redis failures are logged.
+func SetJSON(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	utils.CacheSet(ctx, key, data, ttl)
+	return nil
+}
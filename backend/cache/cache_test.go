@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/testutil"
+)
+
+type item struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestSetJSONThenGetJSON(t *testing.T) {
+	testutil.Config(t)
+	srv := testutil.Redis(t)
+	ctx := context.Background()
+
+	want := []item{{"a", 1}, {"b", 2}}
+	if err := SetJSON(ctx, "items", want, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := srv.TTL("items"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want 1m", ttl)
+	}
+
+	var got []item
+	hit, err := GetJSON(ctx, "items", &got)
+	if !hit || err != nil {
+		t.Fatalf("GetJSON = %v, %v, want a hit", hit, err)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("GetJSON decoded %+v, want %+v", got, want)
+	}
+}
+
+func TestSetJSONWithoutTTL(t *testing.T) {
+	testutil.Config(t)
+	srv := testutil.Redis(t)
+
+	if err := SetJSON(context.Background(), "items", item{"a", 1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := srv.TTL("items"); ttl != 0 {
+		t.Fatalf("TTL = %v, want none", ttl)
+	}
+}
+
+func TestSetJSONEncodingError(t *testing.T) {
+	testutil.Config(t)
+	srv := testutil.Redis(t)
+
+	if err := SetJSON(context.Background(), "items", make(chan int), time.Minute); err == nil {
+		t.Fatal("SetJSON of an unencodable value = nil, want an error")
+	}
+	if srv.Exists("items") {
+		t.Fatal("unencodable value was cached")
+	}
+}
+
+func TestGetJSONMiss(t *testing.T) {
+	testutil.Config(t)
+	testutil.Redis(t)
+
+	var got item
+	if hit, err := GetJSON(context.Background(), "missing", &got); hit || err != nil {
+		t.Fatalf("GetJSON of a missing key = %v, %v, want a plain miss", hit, err)
+	}
+}
+
+func TestGetJSONDropsUndecodableEntry(t *testing.T) {
+	testutil.Config(t)
+	srv := testutil.Redis(t)
+	if err := srv.Set("items", "{not json"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got item
+	hit, err := GetJSON(context.Background(), "items", &got)
+	if hit || err == nil {
+		t.Fatalf("GetJSON of a corrupt entry = %v, %v, want a miss with the decode error", hit, err)
+	}
+	if srv.Exists("items") {
+		t.Fatal("corrupt entry was kept")
+	}
+}
+
+// Runs last: after a Redis failure the cache is bypassed for a while.
+func TestGetJSONRedisDown(t *testing.T) {
+	testutil.Config(t)
+	srv := testutil.Redis(t)
+	srv.Close()
+
+	var got item
+	if hit, err := GetJSON(context.Background(), "items", &got); hit || err != nil {
+		t.Fatalf("GetJSON with Redis down = %v, %v, want a plain miss", hit, err)
+	}
+	if err := SetJSON(context.Background(), "items", item{"a", 1}, time.Minute); err != nil {
+		t.Fatalf("SetJSON with Redis down = %v, want nil", err)
+	}
+}
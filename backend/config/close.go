@@ -0,0 +1,20 @@
+package config
+
+import "github.com/JerryLinyx/FinGOAT/global"
+
+// CloseConnections releases the database pool and the Redis client. It must
+// only be called once the HTTP server and background workers have stopped.
+func CloseConnections() {
+	if global.DB != nil {
+		if sqlDB, err := global.DB.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				global.Logger.Warn("Failed to close database", "error", err)
+			}
+		}
+	}
+	if global.RedisDB != nil {
+		if err := global.RedisDB.Close(); err != nil {
+			global.Logger.Warn("Failed to close Redis", "error", err)
+		}
+	}
+}
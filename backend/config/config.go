@@ -1,15 +1,32 @@
 package config
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
 
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
+	Log struct {
+		Level       string        `yaml:"level"`        // debug / info / warn / error
+		SlowRequest time.Duration `yaml:"slow_request"` // warn about requests slower than this
+		SlowQuery   time.Duration `yaml:"slow_query"`   // warn about database queries slower than this
+	} `yaml:"log"`
 	App struct {
-		Name string `yaml:"name"`
-		Port string `yaml:"port"`
+		Name            string        `yaml:"name"`
+		Env             string        `yaml:"env"` // development (default) or production
+		Port            string        `yaml:"port"`
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+		// Serve HTTPS directly when both are set; plain HTTP otherwise
+		CertFile string `yaml:"cert_file"`
+		KeyFile  string `yaml:"key_file"`
 	} `yaml:"app"`
 	Database struct {
 		Host         string `yaml:"host"`
@@ -21,31 +38,283 @@ type Config struct {
 		Timezone     string `yaml:"timezone"`
 		MaxIdleConns int    `yaml:"max_idle_conns"`
 		MaxOpenConns int    `yaml:"max_open_conns"`
+		// Managed Postgres often drops idle connections on its own; keep these
+		// below the server's limits so the pool retires them first
+		ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+		ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
 	} `yaml:"database"`
 	Redis struct {
 		Addr     string `yaml:"addr"`
 		Password string `yaml:"password"`
 		DB       int    `yaml:"DB"`
+		// Zero values keep the go-redis defaults; MaxRetries -1 disables retries
+		PoolSize     int           `yaml:"pool_size"`
+		MinIdleConns int           `yaml:"min_idle_conns"`
+		MaxRetries   int           `yaml:"max_retries"`
+		DialTimeout  time.Duration `yaml:"dial_timeout"`
+		ReadTimeout  time.Duration `yaml:"read_timeout"`
+		WriteTimeout time.Duration `yaml:"write_timeout"`
 	} `yaml:"redis"`
+	Auth struct {
+		AccessTTL    time.Duration `yaml:"access_ttl"`     // lifetime of issued tokens
+		Issuer       string        `yaml:"issuer"`         // iss claim; tokens from other issuers are rejected
+		Audience     string        `yaml:"audience"`       // aud claim; tokens for other audiences are rejected
+		UserCacheTTL time.Duration `yaml:"user_cache_ttl"` // how long AuthMiddleware caches a user lookup
+		// Tokens are signed with SigningKey and verified with whichever of it
+		// and PreviousKeys has the ID in the token's kid header
+		SigningKey   JWTKey   `yaml:"signing_key"`
+		PreviousKeys []JWTKey `yaml:"previous_keys"`
+		// Requests per minute allowed for each API key; 0 is unlimited
+		APIKeyRateLimit int `yaml:"api_key_rate_limit"`
+	} `yaml:"auth"`
+	OAuth struct {
+		Google struct {
+			ClientID     string `yaml:"client_id"` // Google login is disabled when empty
+			ClientSecret string `yaml:"client_secret"`
+			RedirectURL  string `yaml:"redirect_url"` // must point at /api/v1/auth/oauth/google/callback
+		} `yaml:"google"`
+	} `yaml:"oauth"`
+	Password struct {
+		MinLength     int  `yaml:"min_length"`
+		RequireUpper  bool `yaml:"require_upper"`
+		RequireLower  bool `yaml:"require_lower"`
+		RequireDigit  bool `yaml:"require_digit"`
+		RequireSymbol bool `yaml:"require_symbol"`
+	} `yaml:"password"`
+	Email struct {
+		RequireVerification bool          `yaml:"require_verification"`
+		VerificationTTL     time.Duration `yaml:"verification_ttl"`
+		VerifyURL           string        `yaml:"verify_url"` // the token is appended as ?token=
+		SMTPHost            string        `yaml:"smtp_host"`  // leave empty to disable sending
+		SMTPPort            string        `yaml:"smtp_port"`
+		SMTPUsername        string        `yaml:"smtp_username"`
+		SMTPPassword        string        `yaml:"smtp_password"`
+		From                string        `yaml:"from"`
+	} `yaml:"email"`
+	Trading struct {
+		ServiceURL         string        `yaml:"service_url"` // base URL of the Python trading service
+		ReconcileInterval  time.Duration `yaml:"reconcile_interval"`
+		DefaultLLMProvider string        `yaml:"default_llm_provider"` // used when a request names no provider
+		DefaultLLMModel    string        `yaml:"default_llm_model"`
+		// Providers users may choose, each with the models allowed for it (an
+		// empty list allows any). When empty, any supported provider and model
+		AllowedLLMs map[string][]string `yaml:"allowed_llms"`
+		// Base URLs users may point the service at; once this or AllowedLLMs
+		// is set, any other llm_base_url is rejected
+		AllowedLLMBaseURLs []string `yaml:"allowed_llm_base_urls"`
+		// HTTP client used for calls to the Python service
+		Timeout             time.Duration  `yaml:"timeout"`
+		MaxIdleConns        int            `yaml:"max_idle_conns"`
+		MaxIdleConnsPerHost int            `yaml:"max_idle_conns_per_host"`
+		MaxConnsPerHost     int            `yaml:"max_conns_per_host"`   // 0 means unlimited
+		MaxConcurrentCalls  int            `yaml:"max_concurrent_calls"` // calls in flight at once; others wait for a slot
+		IdleConnTimeout     time.Duration  `yaml:"idle_conn_timeout"`
+		HealthTimeout       time.Duration  `yaml:"health_timeout"`
+		BreakerThreshold    int            `yaml:"breaker_threshold"` // consecutive failures that open the circuit breaker
+		BreakerCooldown     time.Duration  `yaml:"breaker_cooldown"`  // how long the breaker stays open before a probe call
+		IdempotencyTTL      time.Duration  `yaml:"idempotency_ttl"`   // how long an Idempotency-Key is remembered
+		Timezone            string         `yaml:"timezone"`          // decides "today" for analyses requested without a date
+		DailyQuota          int            `yaml:"daily_quota"`       // analyses per user per UTC day; 0 is unlimited
+		RoleQuotas          map[string]int `yaml:"role_quotas"`       // per-role overrides of DailyQuota
+		DryRunEnabled       bool           `yaml:"dry_run_enabled"`   // accept dry_run analyses; keep off in production
+		DryRunDelay         time.Duration  `yaml:"dry_run_delay"`     // how long a dry run stays pending
+		DebugLogging        bool           `yaml:"debug_logging"`     // log redacted service payloads; needs log.level debug
+		CompressReports     bool           `yaml:"compress_reports"`  // store decision reports gzip-compressed
+		NonTradingDays      string         `yaml:"non_trading_days"`  // allow (default), reject or previous: analyses dated on weekends and holidays
+	} `yaml:"trading"`
+	Schedule struct {
+		Enabled    bool          `yaml:"enabled"`
+		Interval   time.Duration `yaml:"interval"`     // how often due schedules are checked
+		Timezone   string        `yaml:"timezone"`     // zone that run times and trading days are in
+		MaxPerUser int           `yaml:"max_per_user"` // schedules each user may have; 0 is unlimited
+	} `yaml:"schedule"`
+	Calendar struct {
+		Holidays []string `yaml:"holidays"` // YYYY-MM-DD market holidays; replaces the bundled NYSE list
+	} `yaml:"calendar"`
+	Webhook struct {
+		MaxAttempts      int           `yaml:"max_attempts"`
+		Timeout          time.Duration `yaml:"timeout"`
+		DispatchInterval time.Duration `yaml:"dispatch_interval"` // how often the outbox is drained
+	} `yaml:"webhook"`
+	FX struct {
+		Enabled          bool          `yaml:"enabled"`
+		ProviderURL      string        `yaml:"provider_url"`
+		BaseCurrency     string        `yaml:"base_currency"`
+		TargetCurrencies []string      `yaml:"target_currencies"`
+		Interval         time.Duration `yaml:"interval"`
+	} `yaml:"fx"`
+	// MarketData looks up ticker metadata; lookups are off until an API key is set
+	MarketData struct {
+		ProviderURL string        `yaml:"provider_url"` // Alpha Vantage compatible OVERVIEW endpoint
+		APIKey      string        `yaml:"api_key"`
+		Timeout     time.Duration `yaml:"timeout"`
+		CacheTTL    time.Duration `yaml:"cache_ttl"` // how long a known ticker is cached; unknown ones for a tenth of it
+	} `yaml:"market_data"`
+	// Internal authenticates service-to-service calls to /internal routes
+	Internal struct {
+		Secret       string        `yaml:"secret"`         // shared with the trading service; empty disables /internal
+		MaxClockSkew time.Duration `yaml:"max_clock_skew"` // how far a signed request's timestamp may be off
+	} `yaml:"internal"`
+	// Retention prunes old finished analysis tasks and their decisions
+	Retention struct {
+		Enabled     bool          `yaml:"enabled"`
+		Interval    time.Duration `yaml:"interval"`      // how often pruning runs
+		MaxAge      time.Duration `yaml:"max_age"`       // finished tasks created longer ago are removed
+		KeepPerUser int           `yaml:"keep_per_user"` // each user's newest tasks are kept regardless of age; 0 keeps none
+		HardDelete  bool          `yaml:"hard_delete"`   // delete rows instead of soft-deleting; also purges soft-deleted tasks past MaxAge
+	} `yaml:"retention"`
+	CORS struct {
+		AllowedOrigins   []string      `yaml:"allowed_origins"` // ["*"] allows any origin without credentials; required in production
+		AllowedMethods   []string      `yaml:"allowed_methods"`
+		AllowedHeaders   []string      `yaml:"allowed_headers"`
+		AllowCredentials *bool         `yaml:"allow_credentials"` // defaults to true for explicit origins
+		MaxAge           time.Duration `yaml:"max_age"`
+	} `yaml:"cors"`
+	Compression struct {
+		Enabled bool `yaml:"enabled"`
+		Level   int  `yaml:"level"`    // 1 (fastest) - 9 (best), -1 for the default
+		MinSize int  `yaml:"min_size"` // responses smaller than this are sent uncompressed
+	} `yaml:"compression"`
+	SecureHeaders struct {
+		Enabled               bool   `yaml:"enabled"`
+		ContentSecurityPolicy string `yaml:"content_security_policy"` // not applied to the Swagger UI
+		ReferrerPolicy        string `yaml:"referrer_policy"`
+	} `yaml:"secure_headers"`
+	BodyLimit struct {
+		MaxBytes int64 `yaml:"max_bytes"`
+		// Per-route limits keyed by route path below the version prefix, e.g.
+		// "/articles/import"; matched case-insensitively
+		Routes map[string]int64 `yaml:"routes"`
+	} `yaml:"body_limit"`
+	RequestTimeout struct {
+		Default time.Duration `yaml:"default"`
+		// Per-route deadlines keyed by route pattern below the version prefix,
+		// e.g. "/articles/:id"; 0 disables the deadline for that route
+		Routes map[string]time.Duration `yaml:"routes"`
+	} `yaml:"request_timeout"`
+	Cache struct {
+		TTL time.Duration `yaml:"ttl"` // lifetime of cached article and exchange rate lists, ±10% jitter
+	} `yaml:"cache"`
+	Articles struct {
+		// How duplicate articles are detected: "link" skips only articles whose
+		// link is already stored, "content" also those with the same title and content
+		Dedup string `yaml:"dedup"`
+	} `yaml:"articles"`
+	// Feed publishes the latest articles as Atom and JSON Feed
+	Feed struct {
+		Title   string        `yaml:"title"`
+		BaseURL string        `yaml:"base_url"` // public URL of the API; taken from the request when empty
+		Size    int           `yaml:"size"`     // number of articles in a feed
+		MaxAge  time.Duration `yaml:"max_age"`  // how long clients and proxies may cache a feed
+	} `yaml:"feed"`
+	Swagger struct {
+		UIEnabled bool `yaml:"ui_enabled"` // /swagger/doc.json is always served
+	} `yaml:"swagger"`
+	Metrics struct {
+		Enabled bool   `yaml:"enabled"`
+		Port    string `yaml:"port"` // serve /metrics on a separate listener when set
+	} `yaml:"metrics"`
+}
+
+// JWTKey is an HMAC key for signing access tokens. ID is sent as the token's
+// kid header; a key with an empty ID matches tokens that have none.
+type JWTKey struct {
+	ID         string `yaml:"id"`
+	Secret     string `yaml:"secret"`
+	SecretFile string `yaml:"secret_file"` // read the secret from this file instead, e.g. a mounted secret
 }
 
 var AppConfig *Config
 
+// Environments app.env can name.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// IsProduction reports whether app.env is production.
+func IsProduction() bool {
+	return AppConfig.App.Env == EnvProduction
+}
+
+// configureEnv lets any setting be overridden from the environment, which
+// takes precedence over the file: database.password is read from
+// FINGOAT_DATABASE_PASSWORD, database.maxIdleConns from
+// FINGOAT_DATABASE_MAXIDLECONNS. List values are comma-separated.
+func configureEnv() {
+	viper.SetEnvPrefix("FINGOAT")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+	// AutomaticEnv only consults the environment for keys viper already
+	// knows, so settings missing from config.yaml must be bound explicitly
+	bindEnv(reflect.TypeOf(Config{}), "")
+}
+
+// bindEnv binds every setting in t, a config struct, below the key prefix.
+// Maps and lists of structs can only be set in the file.
+func bindEnv(t reflect.Type, prefix string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key := prefix + strings.ToLower(field.Name)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			bindEnv(field.Type, key+".")
+		case field.Type.Kind() == reflect.Map,
+			field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+		default:
+			_ = viper.BindEnv(key)
+		}
+	}
+}
+
 func InitConfig() {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath("./config")
 
+	configureEnv()
+
 	err := viper.ReadInConfig()
 	if err != nil {
-		log.Fatalf("Failed to read config file: %v", err)
+		fatal("Failed to read config file", err)
 	}
 
 	AppConfig = &Config{}
 	err = viper.Unmarshal(AppConfig)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal config: %v", err)
+		fatal("Failed to unmarshal config", err)
+	}
+
+	initLogger()
+
+	if err := loadSecretFiles(AppConfig); err != nil {
+		fatal("Failed to read secret files", err)
+	}
+
+	AppConfig.App.Env = strings.ToLower(strings.TrimSpace(AppConfig.App.Env))
+	if AppConfig.App.Env == "" {
+		AppConfig.App.Env = EnvDevelopment
 	}
+	if err := AppConfig.Validate(); err != nil {
+		// Validate joins every problem; log them one per line
+		problems := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			problems = joined.Unwrap()
+		}
+		for _, problem := range problems {
+			global.Logger.Error("Invalid configuration", "problem", problem.Error())
+		}
+		fatal("Refusing to start with an invalid configuration", fmt.Errorf("%d problem(s)", len(problems)))
+	}
+
+	if AppConfig.Auth.SigningKey.Secret == "" {
+		global.Logger.Warn("auth.signingKey.secret is not set; tokens are signed with the built-in development key")
+	}
+	if AppConfig.Trading.DebugLogging && !global.Logger.Enabled(context.Background(), slog.LevelDebug) {
+		global.Logger.Warn("trading.debugLogging has no effect unless log.level is debug")
+	}
+
+	models.CompressAnalysisReports = AppConfig.Trading.CompressReports
 
 	initDB()
 	initRedis()
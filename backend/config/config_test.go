@@ -0,0 +1,53 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestEnvOverridesSettingsMissingFromFile(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	t.Setenv("FINGOAT_APP_NAME", "from-env")
+	t.Setenv("FINGOAT_CORS_ALLOWCREDENTIALS", "false")
+	t.Setenv("FINGOAT_INTERNAL_SECRET", "internal-secret")
+	t.Setenv("FINGOAT_TRADING_BREAKERCOOLDOWN", "45s")
+	t.Setenv("FINGOAT_AUTH_SIGNINGKEY_SECRET", "signing-secret")
+	t.Setenv("FINGOAT_CORS_ALLOWEDORIGINS", "https://a.example,https://b.example")
+
+	configureEnv()
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(strings.NewReader("app:\n  name: from-file\n  port: :3000\n")); err != nil {
+		t.Fatal(err)
+	}
+	var c Config
+	if err := viper.Unmarshal(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.App.Name != "from-env" {
+		t.Errorf("app.name = %q, want the environment to win over the file", c.App.Name)
+	}
+	if c.App.Port != ":3000" {
+		t.Errorf("app.port = %q, want the file value", c.App.Port)
+	}
+	if c.CORS.AllowCredentials == nil || *c.CORS.AllowCredentials {
+		t.Errorf("cors.allowCredentials = %v, want false", c.CORS.AllowCredentials)
+	}
+	if c.Internal.Secret != "internal-secret" {
+		t.Errorf("internal.secret = %q", c.Internal.Secret)
+	}
+	if c.Trading.BreakerCooldown != 45*time.Second {
+		t.Errorf("trading.breakerCooldown = %v, want 45s", c.Trading.BreakerCooldown)
+	}
+	if c.Auth.SigningKey.Secret != "signing-secret" {
+		t.Errorf("auth.signingKey.secret = %q", c.Auth.SigningKey.Secret)
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !slices.Equal(c.CORS.AllowedOrigins, want) {
+		t.Errorf("cors.allowedOrigins = %q, want %q", c.CORS.AllowedOrigins, want)
+	}
+}
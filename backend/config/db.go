@@ -1,35 +1,102 @@
 package config
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// utcNow stamps created_at, updated_at and deleted_at.
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+// scanTimesInUTC makes a connection return timestamptz values in UTC rather
+// than in the process's local zone, so the API always serializes them with a
+// Z suffix whatever database.timezone is.
+func scanTimesInUTC(ctx context.Context, conn *pgx.Conn) error {
+	registerUTCTimestamptz(conn.TypeMap())
+	return nil
+}
+
+func registerUTCTimestamptz(m *pgtype.Map) {
+	m.RegisterType(&pgtype.Type{
+		Name:  "timestamptz",
+		OID:   pgtype.TimestamptzOID,
+		Codec: &pgtype.TimestamptzCodec{ScanLocation: time.UTC},
+	})
+}
+
 func initDB() {
 	dbConf := AppConfig.Database
 
+	sslmode := dbConf.Sslmode
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+	timezone := dbConf.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
 	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable TimeZone=Asia/Shanghai",
-		dbConf.Host, dbConf.Port, dbConf.User, dbConf.Password, dbConf.Name,
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
+		dbConf.Host, dbConf.Port, dbConf.User, dbConf.Password, dbConf.Name, sslmode, timezone,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	pgConf, err := pgx.ParseConfig(dsn)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Invalid database configuration", err)
 	}
+	sqlDB := stdlib.OpenDB(*pgConf, stdlib.OptionAfterConnect(scanTimesInUTC))
 
-	sqlDB, err := db.DB()
-	sqlDB.SetMaxIdleConns(AppConfig.Database.MaxIdleConns)
-	sqlDB.SetMaxOpenConns(AppConfig.Database.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	slowQuery := AppConfig.Log.SlowQuery
+	if slowQuery <= 0 {
+		slowQuery = 200 * time.Millisecond
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		NowFunc: utcNow,
+		Logger:  dbLogger{slowThreshold: slowQuery},
+	})
 	if err != nil {
-		log.Fatalf("Failed to set up database: %v", err)
+		fatal("Failed to connect to database", err)
+	}
+
+	// Zero or negative would mean "no idle connections" / "unlimited"; treat
+	// them as unset instead, here and for the durations below
+	maxIdle := dbConf.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = 10
+	}
+	maxOpen := dbConf.MaxOpenConns
+	if maxOpen <= 0 {
+		maxOpen = 100
+	}
+	maxLifetime := dbConf.ConnMaxLifetime
+	if maxLifetime <= 0 {
+		maxLifetime = time.Hour
+	}
+	maxIdleTime := dbConf.ConnMaxIdleTime
+	if maxIdleTime <= 0 {
+		maxIdleTime = 5 * time.Minute
+	}
+	// An idle timeout past the lifetime would never take effect
+	if maxIdleTime > maxLifetime {
+		global.Logger.Warn("database.connMaxIdleTime exceeds connMaxLifetime, capping it",
+			"connMaxIdleTime", maxIdleTime.String(), "connMaxLifetime", maxLifetime.String())
+		maxIdleTime = maxLifetime
 	}
+	sqlDB.SetMaxIdleConns(maxIdle)
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetConnMaxLifetime(maxLifetime)
+	sqlDB.SetConnMaxIdleTime(maxIdleTime)
 
 	global.DB = db
 }
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/metrics"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dbLogger sends GORM's output to global.Logger. Queries slower than
+// slowThreshold are logged as warnings and counted in
+// fingoat_slow_queries_total; failed queries other than "record not found"
+// are logged too. SQL is logged with placeholders, never with bound values,
+// so passwords and key hashes stay out of the logs.
+type dbLogger struct {
+	slowThreshold time.Duration
+}
+
+func (l dbLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l dbLogger) Info(ctx context.Context, msg string, args ...any) {
+	global.Logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l dbLogger) Warn(ctx context.Context, msg string, args ...any) {
+	global.Logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l dbLogger) Error(ctx context.Context, msg string, args ...any) {
+	global.Logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// ParamsFilter drops the bound values from the SQL passed to Trace.
+func (l dbLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
+
+func (l dbLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := elapsed > l.slowThreshold
+	if !failed && !slow {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []any{"sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds()}
+	if failed {
+		global.Logger.WarnContext(ctx, "query failed", append(attrs, "error", err)...)
+		return
+	}
+	metrics.SlowQueriesTotal.Inc()
+	global.Logger.WarnContext(ctx, "slow query", append(attrs, "threshold_ms", l.slowThreshold.Milliseconds())...)
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"gorm.io/gorm"
+)
+
+// inShanghai runs the test with the process's local zone set to the one the
+// database DSN has historically used, so a time left in local time shows up
+// as +08:00 instead of Z.
+func inShanghai(t *testing.T) {
+	t.Helper()
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	prev := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = prev })
+}
+
+func TestTimestamptzScansAsUTC(t *testing.T) {
+	inShanghai(t)
+	m := pgtype.NewMap()
+	registerUTCTimestamptz(m)
+
+	var ts time.Time
+	if err := m.Scan(pgtype.TimestamptzOID, pgtype.TextFormatCode, []byte("2024-01-02 17:00:00+08"), &ts); err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"2024-01-02T09:00:00Z"`; string(got) != want {
+		t.Fatalf("timestamptz serializes as %s, want %s", got, want)
+	}
+}
+
+func TestModelTimestampsSerializeWithZ(t *testing.T) {
+	inShanghai(t)
+	now := utcNow()
+	got, err := json.Marshal(gorm.Model{CreatedAt: now, UpdatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(got, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"CreatedAt", "UpdatedAt"} {
+		if s, _ := fields[name].(string); !strings.HasSuffix(s, "Z") {
+			t.Errorf("%s = %v, want a Z suffix", name, fields[name])
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+)
+
+func initLogger() {
+	level := slog.LevelInfo
+	if name := strings.TrimSpace(AppConfig.Log.Level); name != "" {
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			global.Logger.Warn("Unknown log level, falling back to info", "level", name)
+			level = slog.LevelInfo
+		}
+	}
+
+	global.Logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(global.Logger)
+}
+
+// fatal logs the error and terminates the process.
+func fatal(msg string, err error) {
+	global.Logger.Error(msg, "error", err)
+	os.Exit(1)
+}
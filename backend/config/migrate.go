@@ -1,7 +1,7 @@
 package config
 
 import (
-	"log"
+	"strings"
 
 	"github.com/JerryLinyx/FinGOAT/global"
 	"github.com/JerryLinyx/FinGOAT/models"
@@ -9,15 +9,177 @@ import (
 
 // MigrateDB runs database migrations
 func MigrateDB() {
-	err := global.DB.AutoMigrate(
+	if err := dedupeTradingDecisions(); err != nil {
+		fatal("Failed to deduplicate trading decisions", err)
+	}
+	if err := dedupeExchangeRates(); err != nil {
+		fatal("Failed to deduplicate exchange rates", err)
+	}
+
+	if err := global.DB.AutoMigrate(Models()...); err != nil {
+		fatal("Failed to migrate database", err)
+	}
+	if err := backfillArticleHashes(); err != nil {
+		fatal("Failed to backfill article content hashes", err)
+	}
+	if err := compressStoredReports(); err != nil {
+		fatal("Failed to compress stored analysis reports", err)
+	}
+	global.Logger.Info("Database migration completed successfully")
+}
+
+// Models lists the tables MigrateDB creates and keeps up to date.
+func Models() []any {
+	return []any{
 		&models.User{},
+		&models.Tag{},
 		&models.Article{},
+		&models.Bookmark{},
+		&models.Comment{},
 		&models.ExchangeRate{},
 		&models.TradingAnalysisTask{},
 		&models.TradingDecision{},
-	)
-	if err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+		&models.WebhookSubscription{},
+		&models.ScheduledAnalysis{},
+		&models.AnalysisPreset{},
+		&models.OutboxEvent{},
+		&models.APIKey{},
+	}
+}
+
+// dedupeTradingDecisions prepares trading_decisions for its unique task_id
+// index. Repeated polling used to insert one decision per poll, so keep only
+// the newest row per task and drop the old non-unique index. Once the unique
+// index exists there is nothing left to do.
+func dedupeTradingDecisions() error {
+	migrator := global.DB.Migrator()
+	if !migrator.HasTable(&models.TradingDecision{}) ||
+		migrator.HasIndex(&models.TradingDecision{}, "idx_trading_decisions_task_id_unique") {
+		return nil
+	}
+	if err := global.DB.Exec(`DELETE FROM trading_decisions a
+		USING trading_decisions b
+		WHERE a.task_id = b.task_id AND a.id < b.id`).Error; err != nil {
+		return err
+	}
+	if migrator.HasIndex(&models.TradingDecision{}, "idx_trading_decisions_task_id") {
+		return migrator.DropIndex(&models.TradingDecision{}, "idx_trading_decisions_task_id")
+	}
+	return nil
+}
+
+// dedupeExchangeRates prepares exchange_rates for its unique (pair, day)
+// index. Every POST used to insert a new row, so backfill the day of existing
+// rows and keep only the latest rate per pair and day.
+func dedupeExchangeRates() error {
+	migrator := global.DB.Migrator()
+	if !migrator.HasTable(&models.ExchangeRate{}) ||
+		migrator.HasIndex(&models.ExchangeRate{}, "idx_exchange_rates_pair_day") {
+		return nil
+	}
+	if !migrator.HasColumn(&models.ExchangeRate{}, "Day") {
+		if err := migrator.AddColumn(&models.ExchangeRate{}, "Day"); err != nil {
+			return err
+		}
+	}
+	if err := global.DB.Exec(`UPDATE exchange_rates
+		SET day = to_char(date AT TIME ZONE 'UTC', 'YYYY-MM-DD')
+		WHERE day IS NULL OR day = ''`).Error; err != nil {
+		return err
+	}
+	return global.DB.Exec(`DELETE FROM exchange_rates a
+		USING exchange_rates b
+		WHERE a.from_currency = b.from_currency AND a.to_currency = b.to_currency
+			AND a.day = b.day AND (a.date < b.date OR (a.date = b.date AND a.id < b.id))`).Error
+}
+
+// backfillArticleHashes sets the content hash of articles stored before
+// articles were deduplicated by content. Of existing duplicates the oldest
+// gets the hash; the others are kept, marked with DuplicateContentHash, so a
+// finished backfill finds nothing left to do. Rows are read in batches, and
+// only when a quick check finds any left to do.
+func backfillArticleHashes() error {
+	if !strings.EqualFold(AppConfig.Articles.Dedup, "content") {
+		return nil
+	}
+	var pending []uint
+	if err := global.DB.Unscoped().Model(&models.Article{}).
+		Where("content_hash IS NULL").Limit(1).
+		Pluck("id", &pending).Error; err != nil || len(pending) == 0 {
+		return err
+	}
+
+	backfilled, duplicates := 0, 0
+	var lastID uint
+	for {
+		var articles []models.Article
+		if err := global.DB.Unscoped().Select("id", "title", "content").
+			Where("content_hash IS NULL AND id > ?", lastID).Order("id").Limit(500).
+			Find(&articles).Error; err != nil {
+			return err
+		}
+		if len(articles) == 0 {
+			break
+		}
+		for _, a := range articles {
+			hash := models.ArticleContentHash(a.Title, a.Content)
+			res := global.DB.Exec(`UPDATE articles SET content_hash = ?
+				WHERE id = ? AND NOT EXISTS (SELECT 1 FROM articles WHERE content_hash = ?)`,
+				hash, a.ID, hash)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				if err := global.DB.Exec(`UPDATE articles SET content_hash = ? WHERE id = ?`,
+					models.DuplicateContentHash(a.ID), a.ID).Error; err != nil {
+					return err
+				}
+				duplicates++
+				continue
+			}
+			backfilled++
+		}
+		lastID = articles[len(articles)-1].ID
+	}
+	if backfilled > 0 || duplicates > 0 {
+		global.Logger.Info("Backfilled article content hashes", "articles", backfilled, "duplicates", duplicates)
+	}
+	return nil
+}
+
+// compressStoredReports moves the reports of decisions saved uncompressed into
+// analysis_report_gz when trading.compressReports is on. Rows are converted
+// in batches; reports already compressed are left alone, and either kind
+// reads correctly if this is interrupted.
+func compressStoredReports() error {
+	if !models.CompressAnalysisReports {
+		return nil
+	}
+	converted := 0
+	for {
+		var decisions []models.TradingDecision
+		if err := global.DB.Unscoped().Select("id", "analysis_report").
+			Where("analysis_report IS NOT NULL").Order("id").Limit(200).
+			Find(&decisions).Error; err != nil {
+			return err
+		}
+		if len(decisions) == 0 {
+			break
+		}
+		for _, d := range decisions {
+			compressed, err := models.CompressReport(*d.AnalysisReport)
+			if err != nil {
+				return err
+			}
+			if err := global.DB.Model(&models.TradingDecision{}).Unscoped().Where("id = ?", d.ID).
+				UpdateColumns(map[string]any{"analysis_report_gz": compressed, "analysis_report": nil}).Error; err != nil {
+				return err
+			}
+		}
+		converted += len(decisions)
+	}
+	if converted > 0 {
+		global.Logger.Info("Compressed stored analysis reports", "decisions", converted)
 	}
-	log.Println("Database migration completed successfully")
+	return nil
 }
@@ -0,0 +1,46 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+)
+
+func TestMigrateDBBackfillsArticleHashes(t *testing.T) {
+	conf := testutil.Config(t)
+	db := testutil.DB(t)
+	// Stored while articles were deduplicated by link only
+	articles := []models.Article{
+		{Title: "Rates hold", Content: "The central bank held rates.", Preview: "Rates"},
+		{Title: "rates hold", Content: "The central bank  held rates.", Preview: "Rates"},
+		{Title: "Stocks rally", Content: "Stocks rallied.", Preview: "Stocks"},
+	}
+	if err := db.Create(&articles).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	conf.Articles.Dedup = "content"
+	config.MigrateDB()
+	// A finished backfill only checks that nothing is left to do
+	queries := testutil.CountQueries(t, db, "articles", 0)
+	config.MigrateDB()
+	if n := queries.Load(); n != 1 {
+		t.Errorf("%d article queries on a second run, want 1", n)
+	}
+
+	var stored []models.Article
+	if err := db.Order("id").Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{
+		models.ArticleContentHash(articles[0].Title, articles[0].Content),
+		models.DuplicateContentHash(articles[1].ID),
+		models.ArticleContentHash(articles[2].Title, articles[2].Content),
+	} {
+		if got := stored[i].ContentHash; got == nil || *got != want {
+			t.Errorf("article %d hash = %v, want %s", i, got, want)
+		}
+	}
+}
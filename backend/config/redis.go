@@ -1,8 +1,6 @@
 package config
 
 import (
-	"log"
-
 	"github.com/JerryLinyx/FinGOAT/global"
 	"github.com/go-redis/redis/v8"
 )
@@ -10,14 +8,22 @@ import (
 func initRedis() {
 	RedisConf := AppConfig.Redis
 	RedisClient := redis.NewClient(&redis.Options{
-		Addr:     RedisConf.Addr,
-		Password: RedisConf.Password,
-		DB:       RedisConf.DB,
+		Addr:         RedisConf.Addr,
+		Password:     RedisConf.Password,
+		DB:           RedisConf.DB,
+		PoolSize:     RedisConf.PoolSize,
+		MinIdleConns: RedisConf.MinIdleConns,
+		MaxRetries:   RedisConf.MaxRetries,
+		DialTimeout:  RedisConf.DialTimeout,
+		ReadTimeout:  RedisConf.ReadTimeout,
+		WriteTimeout: RedisConf.WriteTimeout,
 	})
 
+	// The client reconnects on its own, and the cache falls back to the
+	// database, so an unreachable Redis is not fatal
 	_, err := RedisClient.Ping(RedisClient.Context()).Result()
 	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+		global.Logger.Error("Failed to connect to Redis, continuing without cache", "addr", RedisConf.Addr, "error", err)
 	}
 
 	global.RedisDB = RedisClient
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// secretSettings are the settings that may be read from a file, keyed by
+// their path in config.yaml.
+func secretSettings(c *Config) map[string]*string {
+	return map[string]*string{
+		"database.password":         &c.Database.Password,
+		"redis.password":            &c.Redis.Password,
+		"auth.signingKey.secret":    &c.Auth.SigningKey.Secret,
+		"oauth.google.clientSecret": &c.OAuth.Google.ClientSecret,
+		"email.smtpPassword":        &c.Email.SMTPPassword,
+		"internal.secret":           &c.Internal.Secret,
+		"marketData.apiKey":         &c.MarketData.APIKey,
+	}
+}
+
+// secretFileEnv is the variable naming a file to read the setting at key from,
+// e.g. FINGOAT_DATABASE_PASSWORD_FILE for database.password.
+func secretFileEnv(key string) string {
+	return "FINGOAT_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_")) + "_FILE"
+}
+
+// loadSecretFiles reads secrets mounted as files, as Docker and Kubernetes
+// secrets are. A JWT key's secret_file replaces its secret, and a _FILE
+// variable replaces the setting it names, inline or from the environment.
+// Every unreadable file is reported.
+func loadSecretFiles(c *Config) error {
+	var problems []error
+	keys := []*JWTKey{&c.Auth.SigningKey}
+	for i := range c.Auth.PreviousKeys {
+		keys = append(keys, &c.Auth.PreviousKeys[i])
+	}
+	for _, key := range keys {
+		if key.SecretFile == "" {
+			continue
+		}
+		secret, err := readSecretFile(key.SecretFile)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("JWT key %q: %w", key.ID, err))
+			continue
+		}
+		key.Secret = secret
+	}
+
+	for key, target := range secretSettings(c) {
+		env := secretFileEnv(key)
+		path := os.Getenv(env)
+		if path == "" {
+			continue
+		}
+		secret, err := readSecretFile(path)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", env, err))
+			continue
+		}
+		*target = secret
+	}
+	return errors.Join(problems...)
+}
+
+// readSecretFile returns the file's content without the trailing newline most
+// editors and `echo` add.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	return secret, nil
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Validate checks that the settings the server cannot start without are
+// present and that values are in range. It reports every problem found, not
+// just the first, joined into one error.
+func (c *Config) Validate() error {
+	var problems []error
+	problemf := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+	required := func(key, value string) {
+		if strings.TrimSpace(value) == "" {
+			problemf("%s is required", key)
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		if value != "" && !slices.Contains(allowed, strings.ToLower(value)) {
+			problemf("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value)
+		}
+	}
+	nonNegative := func(key string, value int) {
+		if value < 0 {
+			problemf("%s must not be negative, got %d", key, value)
+		}
+	}
+	address := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if _, port, err := net.SplitHostPort(value); err != nil || port == "" {
+			problemf("%s must be host:port or :port, got %q", key, value)
+		}
+	}
+	absoluteURL := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problemf("%s must be an absolute http(s) URL, got %q", key, value)
+		}
+	}
+	timezone := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if _, err := time.LoadLocation(value); err != nil {
+			problemf("%s: unknown time zone %q", key, value)
+		}
+	}
+	dates := func(key string, values []string) {
+		for _, value := range values {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				problemf("%s: %q is not a YYYY-MM-DD date", key, value)
+			}
+		}
+	}
+
+	if level := strings.TrimSpace(c.Log.Level); level != "" {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			problemf("log.level must be debug, info, warn or error, got %q", level)
+		}
+	}
+
+	oneOf("app.env", c.App.Env, EnvDevelopment, EnvProduction)
+	address("app.port", c.App.Port)
+	if (c.App.CertFile == "") != (c.App.KeyFile == "") {
+		problemf("app.certFile and app.keyFile must be set together")
+	}
+
+	required("database.host", c.Database.Host)
+	required("database.user", c.Database.User)
+	required("database.name", c.Database.Name)
+	oneOf("database.sslmode", c.Database.Sslmode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	timezone("database.timezone", c.Database.Timezone)
+	if c.Database.MaxIdleConns > 0 && c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problemf("database.maxIdleConns (%d) must not exceed database.maxOpenConns (%d)",
+			c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+
+	required("redis.addr", c.Redis.Addr)
+	address("redis.addr", c.Redis.Addr)
+	nonNegative("redis.DB", c.Redis.DB)
+
+	if c.App.Env == EnvProduction {
+		required("auth.signingKey.secret", c.Auth.SigningKey.Secret)
+	}
+	for i, key := range c.Auth.PreviousKeys {
+		required(fmt.Sprintf("auth.previousKeys[%d].secret", i), key.Secret)
+	}
+	nonNegative("auth.apiKeyRateLimit", c.Auth.APIKeyRateLimit)
+
+	if google := c.OAuth.Google; google.ClientID != "" {
+		required("oauth.google.clientSecret", google.ClientSecret)
+		required("oauth.google.redirectURL", google.RedirectURL)
+		absoluteURL("oauth.google.redirectURL", google.RedirectURL)
+	}
+
+	nonNegative("password.minLength", c.Password.MinLength)
+	absoluteURL("email.verifyURL", c.Email.VerifyURL)
+
+	required("trading.serviceURL", c.Trading.ServiceURL)
+	absoluteURL("trading.serviceURL", c.Trading.ServiceURL)
+	timezone("trading.timezone", c.Trading.Timezone)
+	nonNegative("trading.dailyQuota", c.Trading.DailyQuota)
+	for role, quota := range c.Trading.RoleQuotas {
+		nonNegative("trading.roleQuotas."+role, quota)
+	}
+	nonNegative("trading.maxConcurrentCalls", c.Trading.MaxConcurrentCalls)
+	nonNegative("trading.breakerThreshold", c.Trading.BreakerThreshold)
+	oneOf("trading.nonTradingDays", c.Trading.NonTradingDays, "allow", "reject", "previous")
+
+	timezone("schedule.timezone", c.Schedule.Timezone)
+	nonNegative("schedule.maxPerUser", c.Schedule.MaxPerUser)
+	dates("calendar.holidays", c.Calendar.Holidays)
+
+	nonNegative("webhook.maxAttempts", c.Webhook.MaxAttempts)
+	if c.FX.Enabled {
+		absoluteURL("fx.providerURL", c.FX.ProviderURL)
+	}
+	absoluteURL("marketData.providerURL", c.MarketData.ProviderURL)
+	nonNegative("retention.keepPerUser", c.Retention.KeepPerUser)
+
+	if c.Compression.Enabled && (c.Compression.Level < -2 || c.Compression.Level > 9) {
+		problemf("compression.level must be between -2 and 9, got %d", c.Compression.Level)
+	}
+	oneOf("articles.dedup", c.Articles.Dedup, "link", "content")
+	absoluteURL("feed.baseURL", c.Feed.BaseURL)
+	address("metrics.port", c.Metrics.Port)
+
+	return errors.Join(problems...)
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func validConfig() *Config {
+	var c Config
+	c.Database.Host = "localhost"
+	c.Database.User = "postgres"
+	c.Database.Name = "fingoat_db"
+	c.Redis.Addr = "localhost:6379"
+	c.Trading.ServiceURL = "http://localhost:8001"
+	return &c
+}
+
+func TestValidateAcceptsMinimalConfig(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestValidateReportsMissingFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"database host", func(c *Config) { c.Database.Host = "" }, "database.host is required"},
+		{"database user", func(c *Config) { c.Database.User = " " }, "database.user is required"},
+		{"database name", func(c *Config) { c.Database.Name = "" }, "database.name is required"},
+		{"redis addr", func(c *Config) { c.Redis.Addr = "" }, "redis.addr is required"},
+		{"trading service URL", func(c *Config) { c.Trading.ServiceURL = "" }, "trading.serviceURL is required"},
+		{"relative trading service URL", func(c *Config) { c.Trading.ServiceURL = "trading-service:8001" }, "trading.serviceURL must be an absolute http(s) URL"},
+		{"production signing key", func(c *Config) { c.App.Env = EnvProduction }, "auth.signingKey.secret is required"},
+		{"previous key secret", func(c *Config) { c.Auth.PreviousKeys = []JWTKey{{ID: "old"}} }, "auth.previousKeys[0].secret is required"},
+		{"google client secret", func(c *Config) { c.OAuth.Google.ClientID = "id" }, "oauth.google.clientSecret is required"},
+		{"google redirect URL", func(c *Config) { c.OAuth.Google.ClientID = "id" }, "oauth.google.redirectURL is required"},
+		{"key file", func(c *Config) { c.App.CertFile = "cert.pem" }, "app.certFile and app.keyFile must be set together"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+			err := c.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate() = %v, want it to report %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	c := validConfig()
+	c.Database.Host = ""
+	c.Redis.Addr = ""
+	c.Trading.BreakerThreshold = -1
+	c.Database.MaxIdleConns, c.Database.MaxOpenConns = 20, 10
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	for _, want := range []string{
+		"database.host is required",
+		"redis.addr is required",
+		"trading.breakerThreshold must not be negative",
+		"database.maxIdleConns (20) must not exceed database.maxOpenConns (10)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want it to report %q", err, want)
+		}
+	}
+}
+
+// yamlKeys returns the dotted path of every key in config.yaml.
+func yamlKeys(t *testing.T) map[string]bool {
+	t.Helper()
+	data, err := os.ReadFile("config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyLine := regexp.MustCompile(`^( *)(\w+):`)
+	keys := map[string]bool{}
+	var path []string
+	for _, line := range strings.Split(string(data), "\n") {
+		m := keyLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		depth := len(m[1]) / 2
+		path = append(path[:min(depth, len(path))], m[2])
+		keys[strings.Join(path, ".")] = true
+	}
+	return keys
+}
+
+// Every key named in a validation message is spelled the way config.yaml
+// spells it.
+func TestValidateNamesConfigKeys(t *testing.T) {
+	c := validConfig()
+	c.Database.Host = ""
+	c.Database.MaxIdleConns, c.Database.MaxOpenConns = 20, 10
+	c.Redis.DB = -1
+	c.App.Env = EnvProduction
+	c.App.CertFile = "cert.pem"
+	c.Auth.PreviousKeys = []JWTKey{{ID: "old"}}
+	c.Auth.APIKeyRateLimit = -1
+	c.OAuth.Google.ClientID = "id"
+	c.Password.MinLength = -1
+	c.Email.VerifyURL = "not a url"
+	c.Trading.ServiceURL = "not a url"
+	c.Trading.DailyQuota = -1
+	c.Trading.RoleQuotas = map[string]int{"admin": -1}
+	c.Trading.MaxConcurrentCalls = -1
+	c.Trading.BreakerThreshold = -1
+	c.Trading.NonTradingDays = "sometimes"
+	c.Schedule.MaxPerUser = -1
+	c.Webhook.MaxAttempts = -1
+	c.FX.Enabled = true
+	c.FX.ProviderURL = "not a url"
+	c.MarketData.ProviderURL = "not a url"
+	c.Retention.KeepPerUser = -1
+	c.Feed.BaseURL = "not a url"
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	keys := yamlKeys(t)
+	keyPath := regexp.MustCompile(`\b\w+(\.\w+)+`)
+	for _, line := range strings.Split(err.Error(), "\n") {
+		// Entries of a list such as auth.previousKeys aren't in the file
+		line, _, _ = strings.Cut(line, "[")
+		for _, key := range keyPath.FindAllString(line, -1) {
+			if !keys[key] {
+				t.Errorf("%q names %s, which is not a config.yaml key", line, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminUpdateUserInput holds the account fields an admin can change; omitted
+// fields are left as they are.
+type AdminUpdateUserInput struct {
+	Role   *string `json:"role" binding:"omitempty,oneof=user admin"`
+	Active *bool   `json:"active"`
+}
+
+// ListUsers returns registered users, optionally filtered by a search term
+// @Summary      List users
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q          query     string  false  "Case-insensitive match on username or email"
+// @Param        page       query     int     false  "Page number"
+// @Param        page_size  query     int     false  "Page size"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]string
+// @Router       /api/v1/admin/users [get]
+func ListUsers(c *gin.Context) {
+	offset, limit := pagination.Parse(c)
+	query := global.DB.Model(&models.User{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := "%" + escapeLike(q) + "%"
+		query = query.Where("username ILIKE ? OR email ILIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	var users []models.User
+	if err := query.Session(&gorm.Session{}).Order("id").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	profiles := make([]UserProfile, len(users))
+	for i := range users {
+		profiles[i] = newUserProfile(&users[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":      profiles,
+		"pagination": pagination.NewMeta(offset, limit, total),
+	})
+}
+
+// UpdateUser changes a user's role or active flag. Admins cannot change their
+// own account, so the last admin cannot lock everyone out by accident.
+// @Summary      Update a user's role or status
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int                   true  "User ID"
+// @Param        body  body      AdminUpdateUserInput  true  "Fields to update"
+// @Success      200   {object}  UserProfile
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Router       /api/v1/admin/users/{id} [patch]
+func UpdateUser(c *gin.Context) {
+	var input AdminUpdateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := loadManagedUser(c)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.Role != nil {
+		user.Role = *input.Role
+		updates["role"] = user.Role
+	}
+	if input.Active != nil {
+		user.Active = *input.Active
+		updates["active"] = user.Active
+	}
+	if len(updates) > 0 {
+		if err := global.DB.Model(user).Updates(updates).Error; err != nil {
+			respondDBError(c, err)
+			return
+		}
+		middlewares.InvalidateAuthUser(c.Request.Context(), user.Username)
+	}
+
+	c.JSON(http.StatusOK, newUserProfile(user))
+}
+
+// DeactivateUser disables an account without deleting it. The user can no
+// longer log in, and tokens already issued are rejected from the next request.
+// @Summary      Deactivate a user
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  UserProfile
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/admin/users/{id}/deactivate [post]
+func DeactivateUser(c *gin.Context) {
+	setUserActive(c, false)
+}
+
+// ReactivateUser re-enables a deactivated account
+// @Summary      Reactivate a user
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  UserProfile
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/admin/users/{id}/reactivate [post]
+func ReactivateUser(c *gin.Context) {
+	setUserActive(c, true)
+}
+
+func setUserActive(c *gin.Context, active bool) {
+	user, ok := loadManagedUser(c)
+	if !ok {
+		return
+	}
+
+	if user.Active != active {
+		user.Active = active
+		if err := global.DB.Model(user).Update("active", active).Error; err != nil {
+			respondDBError(c, err)
+			return
+		}
+		// Drop the cached account so sessions see the change immediately
+		middlewares.InvalidateAuthUser(c.Request.Context(), user.Username)
+	}
+
+	c.JSON(http.StatusOK, newUserProfile(user))
+}
+
+// loadManagedUser loads the user named by the :id parameter for an admin
+// change, writing the error response and returning false if there is none or
+// it is the admin's own account.
+func loadManagedUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	if err := global.DB.First(&user, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return nil, false
+	}
+	if user.ID == c.GetUint("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot change your own account"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// apiKeyPrefix marks FinGOAT keys so they are recognizable, e.g. by secret scanners
+	apiKeyPrefix = "fgk_"
+	// maxAPIKeysPerUser bounds the live keys a user may hold
+	maxAPIKeysPerUser = 20
+)
+
+type APIKeyInput struct {
+	Label    string `json:"label" binding:"required,max=100"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// CreatedAPIKey is a new key with its plaintext, which is never shown again.
+type CreatedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// newAPIKey returns a random key and the prefix listed to identify it.
+func newAPIKey() (key, prefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:len(apiKeyPrefix)+8], nil
+}
+
+// CreateAPIKey issues an API key for scripts to send in X-API-Key instead of a
+// bearer token. The key is returned once; only its hash is stored.
+// @Summary      Create an API key
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      APIKeyInput  true  "Label and scope"
+// @Success      201   {object}  CreatedAPIKey
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Router       /api/v1/auth/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var input APIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := c.GetUint("user_id")
+
+	var live int64
+	if err := global.DB.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Count(&live).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	if live >= maxAPIKeysPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": "too many API keys, revoke one first"})
+		return
+	}
+
+	key, prefix, err := newAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	apiKey := models.APIKey{
+		UserID:   userID,
+		Label:    input.Label,
+		Prefix:   prefix,
+		KeyHash:  middlewares.HashAPIKey(key),
+		ReadOnly: input.ReadOnly,
+	}
+	if err := global.DB.Create(&apiKey).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreatedAPIKey{APIKey: apiKey, Key: key})
+}
+
+// ListAPIKeys lists the user's API keys, revoked ones included, newest first
+// @Summary      List API keys
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.APIKey
+// @Router       /api/v1/auth/api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+	keys := []models.APIKey{}
+	if err := global.DB.Where("user_id = ?", c.GetUint("user_id")).
+		Order("created_at DESC, id DESC").
+		Find(&keys).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey stops an API key from working. Revoking is immediate and cannot
+// be undone.
+// @Summary      Revoke an API key
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "API key ID"
+// @Success      200  {object}  models.APIKey
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/auth/api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	var key models.APIKey
+	if err := global.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).
+		First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	if key.RevokedAt == nil {
+		now := time.Now().UTC()
+		if err := global.DB.Model(&key).Update("revoked_at", now).Error; err != nil {
+			respondDBError(c, err)
+			return
+		}
+		key.RevokedAt = &now
+		middlewares.InvalidateAPIKey(c.Request.Context(), key.KeyHash)
+	}
+	c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+// getConcurrently serves n simultaneous GETs of path and fails the test unless
+// all of them succeed.
+func getConcurrently(t *testing.T, r http.Handler, path string, n int) {
+	t.Helper()
+	start := make(chan struct{})
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			codes[i] = testutil.Do(r, http.MethodGet, path, "").Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i, code)
+		}
+	}
+}
+
+func TestGetArticlesColdCacheQueriesOnce(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	for i := range 3 {
+		if err := db.Create(&models.Article{Title: fmt.Sprint("article ", i), Content: "body", Preview: "body"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	queries := testutil.CountQueries(t, db, "articles", 100*time.Millisecond)
+
+	r := gin.New()
+	r.GET("/articles", GetArticles)
+	getConcurrently(t, r, "/articles", 20)
+
+	// One count and one page query
+	if n := queries.Load(); n != 2 {
+		t.Fatalf("%d article queries for 20 concurrent cache misses, want 2", n)
+	}
+	// Later requests are served from the cache
+	getConcurrently(t, r, "/articles", 5)
+	if n := queries.Load(); n != 2 {
+		t.Fatalf("%d article queries after the cache was filled, want 2", n)
+	}
+}
+
+func TestGetExchangeRatesColdCacheQueriesOnce(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	rate := models.ExchangeRate{FromCurrency: "USD", ToCurrency: "EUR", Rate: 0.9, Date: time.Now().UTC(), Day: time.Now().UTC().Format("2006-01-02")}
+	if err := db.Create(&rate).Error; err != nil {
+		t.Fatal(err)
+	}
+	queries := testutil.CountQueries(t, db, "exchange_rates", 100*time.Millisecond)
+
+	r := gin.New()
+	r.GET("/exchangeRates", GetExchangeRates)
+	getConcurrently(t, r, "/exchangeRates", 20)
+
+	if n := queries.Load(); n != 2 {
+		t.Fatalf("%d exchange rate queries for 20 concurrent cache misses, want 2: a count and a page", n)
+	}
+}
+
+func TestGetArticlesAfterInvalidationQueriesOnce(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	if err := db.Create(&models.Article{Title: "first", Content: "body", Preview: "body"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	queries := testutil.CountQueries(t, db, "articles", 100*time.Millisecond)
+
+	r := gin.New()
+	r.GET("/articles", GetArticles)
+
+	// A rebuild is in flight when an article is added; requests arriving after
+	// the invalidation must not be handed its stale result
+	slow := make(chan struct{})
+	go func() {
+		defer close(slow)
+		testutil.Do(r, http.MethodGet, "/articles", "")
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := db.Create(&models.Article{Title: "second", Content: "body", Preview: "body"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	invalidateArticlesCache(context.Background())
+
+	getConcurrently(t, r, "/articles", 20)
+	<-slow
+	if n := queries.Load(); n != 4 {
+		t.Fatalf("%d article queries, want 4: the stale rebuild and one after the invalidation, each a count and a page", n)
+	}
+	w := testutil.Do(r, http.MethodGet, "/articles", "")
+	var resp struct {
+		Articles []models.Article `json:"articles"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Articles) != 2 {
+		t.Fatalf("%d articles after the invalidation, want 2", len(resp.Articles))
+	}
+}
+
+func TestGetArticlesPagesInDatabase(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	for _, title := range []string{"b", "c", "a"} {
+		if err := db.Create(&models.Article{Title: title, Content: "body", Preview: "body"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	queries := testutil.CountQueries(t, db, "articles", 0)
+
+	r := gin.New()
+	r.GET("/articles", GetArticles)
+	page := func(n int) (string, int64) {
+		t.Helper()
+		w := testutil.Do(r, http.MethodGet, fmt.Sprintf("/articles?sort=title&order=asc&page_size=1&page=%d", n), "")
+		var resp struct {
+			Articles   []models.Article `json:"articles"`
+			Pagination struct {
+				Total int64 `json:"total"`
+			} `json:"pagination"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Articles) != 1 {
+			t.Fatalf("page %d has %d articles, want 1", n, len(resp.Articles))
+		}
+		return resp.Articles[0].Title, resp.Pagination.Total
+	}
+
+	for n, want := range []string{"a", "b", "c"} {
+		if title, total := page(n + 1); title != want || total != 3 {
+			t.Fatalf("page %d = %q of %d, want %q of 3", n+1, title, total, want)
+		}
+	}
+	// Each page is cached under its own key
+	page(2)
+	if n := queries.Load(); n != 6 {
+		t.Fatalf("%d article queries, want 6: a count and a page for each of the 3 pages", n)
+	}
+}
+
+func TestGetArticlesServedFromDatabaseWhenRedisIsDown(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	redis := testutil.Redis(t)
+	if err := db.Create(&models.Article{Title: "first", Content: "body", Preview: "body"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	redis.Close()
+
+	r := gin.New()
+	r.GET("/articles", GetArticles)
+	w := testutil.Do(r, http.MethodGet, "/articles", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d with Redis down, want 200: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Articles []models.Article `json:"articles"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Articles) != 1 || resp.Articles[0].Title != "first" {
+		t.Fatalf("articles = %+v, want the one stored", resp.Articles)
+	}
+}
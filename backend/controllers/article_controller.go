@@ -1,20 +1,48 @@
 package controllers
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"time"
 
+	"github.com/JerryLinyx/FinGOAT/cache"
+	"github.com/JerryLinyx/FinGOAT/config"
 	"github.com/JerryLinyx/FinGOAT/global"
 	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/pagination"
+	"github.com/JerryLinyx/FinGOAT/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// cacheKey prefixes the cached pages of the article listing. Keys carry the
+// listing version, so a write retires every cached page at once.
 var cacheKey = "articles"
 
+// articlesFlight coalesces concurrent cache misses so that only one request
+// queries the database and repopulates the cache while the others wait.
+var articlesFlight singleflight.Group
+
+// articlesPage is one cached page of the unfiltered article listing.
+type articlesPage struct {
+	Articles []models.Article `json:"articles"`
+	Total    int64            `json:"total"`
+}
+
+// @Summary      Create an article
+// @Tags         articles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      models.Article  true  "Article"
+// @Success      201   {object}  models.Article
+// @Failure      400   {object}  map[string]string
+// @Router       /api/v1/articles [post]
 func CreateArticle(c *gin.Context) {
 	var article models.Article
 	if err := c.ShouldBindJSON(&article); err != nil {
@@ -22,63 +50,277 @@ func CreateArticle(c *gin.Context) {
 		return
 	}
 	if err := global.DB.AutoMigrate(&article); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondDBError(c, err)
 		return
 	}
-	if err := global.DB.Create(&article).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	setContentHash(&article)
+	if err := global.DB.Omit(clause.Associations).Create(&article).Error; err != nil {
+		respondDBError(c, err)
 		return
 	}
 
 	// 缓存失效：异步/不阻断主流程
-	go func() {
-		_ = global.RedisDB.Del(c.Request.Context(), cacheKey).Err()
-	}()
+	go invalidateArticlesCache(context.Background())
 
 	c.JSON(http.StatusCreated, article)
 }
 
+// @Summary      List articles
+// @Tags         articles
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tag            query     string  false  "Only articles with this tag"
+// @Param        sort           query     string  false  "Sort field: published_at (default), created_at or title"
+// @Param        order          query     string  false  "Sort direction: asc or desc (default)"
+// @Param        page           query     int     false  "Page (default 1)"
+// @Param        page_size      query     int     false  "Page size (default 20, max 100)"
+// @Param        If-None-Match  header    string  false  "ETag of a previously fetched page"
+// @Success      200            {object}  map[string]interface{}
+// @Success      304            "Page unchanged"
+// @Failure      400            {object}  map[string]string
+// @Failure      500            {object}  map[string]string
+// @Router       /api/v1/articles [get]
 func GetArticles(c *gin.Context) {
 
 	var articles []models.Article
 	ctx := c.Request.Context()
+	offset, limit := pagination.Parse(c)
+	sort, err := parseArticleSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if cachedData, err := global.RedisDB.Get(ctx, cacheKey).Result(); err == nil {
-		if err := json.Unmarshal([]byte(cachedData), &articles); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	tag := normalizeTagName(c.Query("tag"))
+	// Read before the listing is, so neither the ETag nor the cache key is
+	// ever newer than the data
+	version := articlesVersion(ctx)
+	etag := articlesETag(version, tag, sort, offset, limit)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// Filtered listings are not cached
+	if tag != "" {
+		query := global.DB.WithContext(ctx).Model(&models.Article{}).
+			Joins("JOIN article_tags ON article_tags.article_id = articles.id").
+			Joins("JOIN tags ON tags.id = article_tags.tag_id").
+			Where("tags.name = ?", tag)
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			respondDBError(c, err)
 			return
 		}
-	} else if err == redis.Nil {
-		if err := global.DB.Find(&articles).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := query.Session(&gorm.Session{}).
+			Preload("Tags").
+			Order(sort.orderClause()).
+			Offset(offset).
+			Limit(limit).
+			Find(&articles).Error; err != nil {
+			respondDBError(c, err)
 			return
 		}
-		articlesJSON, err := json.Marshal(articles)
+		c.JSON(http.StatusOK, gin.H{
+			"articles":   articles,
+			"pagination": pagination.NewMeta(offset, limit, total),
+		})
+		return
+	}
+
+	key := fmt.Sprintf("%s:%s:%s:%t:%d:%d", cacheKey, version, sort.Field, sort.Desc, offset, limit)
+	page, err := cachedLoad(ctx, &articlesFlight, key, func(ctx context.Context) (articlesPage, error) {
+		return loadArticlesPage(ctx, sort, offset, limit)
+	})
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"articles":   page.Articles,
+		"pagination": pagination.NewMeta(offset, limit, page.Total),
+	})
+}
+
+// loadArticlesPage reads one page of the unfiltered listing and the total
+// number of articles.
+func loadArticlesPage(ctx context.Context, sort articleSort, offset, limit int) (articlesPage, error) {
+	page := articlesPage{Articles: []models.Article{}}
+	query := global.DB.WithContext(ctx).Model(&models.Article{})
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	err := query.Session(&gorm.Session{}).
+		Preload("Tags").
+		Order(sort.orderClause()).
+		Offset(offset).
+		Limit(limit).
+		Find(&page.Articles).Error
+	return page, err
+}
+
+// cachedLoad returns the value cached under key, or loads it and writes it to
+// the cache, with concurrent misses coalesced through flight. An unreadable or
+// unavailable cache falls through to load. The load runs detached from the
+// calling request because its result is shared with every request waiting on
+// the same singleflight key; failing to write the cache is logged but does not
+// fail them.
+func cachedLoad[T any](ctx context.Context, flight *singleflight.Group, key string, load func(context.Context) (T, error)) (T, error) {
+	var v T
+	if hit, _ := cache.GetJSON(ctx, key, &v); hit {
+		return v, nil
+	}
+	res, err, _ := flight.Do(key, func() (interface{}, error) {
+		ctx := context.WithoutCancel(ctx)
+		v, err := load(ctx)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, err
 		}
-		if err := global.RedisDB.Set(ctx, cacheKey, articlesJSON, 10*time.Minute).Err(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		if err := cache.SetJSON(ctx, key, v, cacheTTL()); err != nil {
+			return nil, err
 		}
-	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return v, nil
+	})
+	if err != nil {
+		return v, err
 	}
-	c.JSON(http.StatusOK, articles)
+	return res.(T), nil
+}
+
+// cacheTTL returns the configured cache lifetime (10 minutes by default) with
+// ±10% random jitter so that cached entries don't all expire at once.
+func cacheTTL() time.Duration {
+	ttl := config.AppConfig.Cache.TTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	jitter := time.Duration(rand.Int64N(int64(ttl)/5+1)) - ttl/10
+	return ttl + jitter
+}
+
+// invalidateArticlesCache bumps the listing version, which retires the cached
+// pages and the listing ETags; the old pages expire with their TTL. Requests
+// arriving after this use new keys, so they never join a rebuild that started
+// before the write.
+func invalidateArticlesCache(ctx context.Context) {
+	utils.CacheIncr(ctx, articlesVersionKey)
 }
 
+// @Summary      Get an article
+// @Tags         articles
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Article ID"
+// @Success      200  {object}  models.Article
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/articles/{id} [get]
 func GetArticlesByID(c *gin.Context) {
 	id := c.Param("id")
 	var article models.Article
-	if err := global.DB.Where("id = ?", id).First(&article).Error; err != nil {
+	if err := global.DB.Preload("Tags").Where("id = ?", id).First(&article).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			c.JSON(http.StatusNotFound, gin.H{"error": "article not found"})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondDBError(c, err)
 		}
 		return
 	}
 	c.JSON(http.StatusOK, article)
 }
+
+// DeleteArticle soft-deletes an article; it can be brought back with RestoreArticle
+// @Summary      Delete an article
+// @Tags         articles
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  int  true  "Article ID"
+// @Success      204
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/articles/{id} [delete]
+func DeleteArticle(c *gin.Context) {
+	result := global.DB.Where("id = ?", c.Param("id")).Delete(&models.Article{})
+	if result.Error != nil {
+		respondDBError(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "article not found"})
+		return
+	}
+
+	invalidateArticlesCache(c.Request.Context())
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDeletedArticles lists soft-deleted articles, most recently deleted first
+// @Summary      List deleted articles
+// @Tags         articles
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page       query     int  false  "Page (default 1)"
+// @Param        page_size  query     int  false  "Page size (default 20, max 100)"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]string
+// @Router       /api/v1/articles/trash [get]
+func GetDeletedArticles(c *gin.Context) {
+	offset, limit := pagination.Parse(c)
+	query := global.DB.Unscoped().Model(&models.Article{}).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	var articles []models.Article
+	if err := query.Session(&gorm.Session{}).
+		Order("deleted_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&articles).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"articles":   articles,
+		"pagination": pagination.NewMeta(offset, limit, total),
+	})
+}
+
+// RestoreArticle undoes a soft delete
+// @Summary      Restore a deleted article
+// @Tags         articles
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Article ID"
+// @Success      200  {object}  models.Article
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/articles/{id}/restore [post]
+func RestoreArticle(c *gin.Context) {
+	result := global.DB.Unscoped().Model(&models.Article{}).
+		Where("id = ? AND deleted_at IS NOT NULL", c.Param("id")).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		respondDBError(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "deleted article not found"})
+		return
+	}
+
+	invalidateArticlesCache(c.Request.Context())
+
+	var article models.Article
+	if err := global.DB.Preload("Tags").First(&article, c.Param("id")).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
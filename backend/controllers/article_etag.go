@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/utils"
+)
+
+// articlesVersionKey holds a counter bumped by every write that can change an
+// article listing. ETags are derived from it, so checking one costs a single
+// Redis read instead of building the listing.
+const articlesVersionKey = "articles:version"
+
+// articlesETag returns the ETag for one page of the article listing. The tag
+// filter, sort and page are part of it, so each page validates separately.
+// While Redis is unavailable every call yields a new ETag, so nothing stale is
+// ever confirmed.
+func articlesETag(version, tag string, sort articleSort, offset, limit int) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%s|%t|%d|%d", version, tag, sort.Field, sort.Desc, offset, limit))
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// articlesVersion returns the current value of the listing version counter.
+func articlesVersion(ctx context.Context) string {
+	return cacheVersion(ctx, articlesVersionKey)
+}
+
+// cacheVersion returns the current value of the version counter at key. Cache
+// keys and ETags that include it are retired by bumping it with CacheIncr.
+func cacheVersion(ctx context.Context, key string) string {
+	version, hit := utils.CacheGet(ctx, key)
+	if !hit {
+		// First use, or Redis lost the counter: start from a value no earlier
+		// key or ETag can have been built from
+		version = utils.CacheSetNX(ctx, key, strconv.FormatInt(time.Now().UnixNano(), 10), 0)
+	}
+	return version
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag,
+// using the weak comparison that conditional GETs call for.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Media types of the published feeds.
+const (
+	atomMediaType     = "application/atom+xml; charset=utf-8"
+	jsonFeedMediaType = "application/feed+json; charset=utf-8"
+	jsonFeedVersion   = "https://jsonfeed.org/version/1.1"
+)
+
+// Feed paths, below the API prefix. The canonical /api/v1 form is used in
+// feed and entry IDs so they don't change with the alias a client used.
+const (
+	atomFeedPath = "/api/v1/feed.atom"
+	jsonFeedPath = "/api/v1/feed.json"
+)
+
+// feedArticles returns the newest articles for a feed, newest first, cached
+// under the given listing version. Articles without a publication time are
+// dated by their creation.
+func feedArticles(ctx context.Context, version string) ([]models.Article, error) {
+	size := config.AppConfig.Feed.Size
+	if size <= 0 {
+		size = 20
+	}
+	key := fmt.Sprintf("%s:%s:feed:%d", cacheKey, version, size)
+	return cachedLoad(ctx, &articlesFlight, key, func(ctx context.Context) ([]models.Article, error) {
+		articles := []models.Article{}
+		err := global.DB.WithContext(ctx).
+			Preload("Tags").
+			Order("COALESCE(published_at, created_at) DESC, id DESC").
+			Limit(size).
+			Find(&articles).Error
+		return articles, err
+	})
+}
+
+func articleDate(a models.Article) time.Time {
+	if a.PublishedAt != nil {
+		return *a.PublishedAt
+	}
+	return a.CreatedAt
+}
+
+// feedBaseURL is feed.baseURL, or the scheme and host the request came in on.
+func feedBaseURL(c *gin.Context) string {
+	if base := config.AppConfig.Feed.BaseURL; base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// articleFeedID is an article's stable ID in feeds: its original link when it
+// was imported, its API URL otherwise.
+func articleFeedID(base string, a models.Article) string {
+	if a.Link != "" {
+		return a.Link
+	}
+	return base + "/api/v1/articles/" + strconv.FormatUint(uint64(a.ID), 10)
+}
+
+// serveFeed answers a conditional request from the article listing version, or
+// renders the feed with render and sends it with the given media type.
+func serveFeed(c *gin.Context, mediaType string, render func(base string, articles []models.Article) ([]byte, error)) {
+	ctx := c.Request.Context()
+	base := feedBaseURL(c)
+	version := articlesVersion(ctx)
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%s|%d", version, mediaType, base, config.AppConfig.Feed.Size))
+	etag := `W/"` + hex.EncodeToString(sum[:8]) + `"`
+
+	maxAge := config.AppConfig.Feed.MaxAge
+	if maxAge <= 0 {
+		maxAge = 5 * time.Minute
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	articles, err := feedArticles(ctx, version)
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+	body, err := render(base, articles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render feed: " + err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, mediaType, body)
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Author    atomPerson  `xml:"author"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      atomText       `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Links      []atomLink     `xml:"link"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    atomText       `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func renderAtomFeed(base string, articles []models.Article) ([]byte, error) {
+	title := config.AppConfig.Feed.Title
+	if title == "" {
+		title = "FinGOAT"
+	}
+	feed := atomFeed{
+		ID:    base + atomFeedPath,
+		Title: title,
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: base + atomFeedPath},
+			{Rel: "alternate", Type: "application/feed+json", Href: base + jsonFeedPath},
+		},
+		Author:    atomPerson{Name: title},
+		Generator: "FinGOAT",
+	}
+
+	// An empty feed still needs a fixed updated time, or every fetch differs
+	var updated time.Time
+	for _, a := range articles {
+		if a.UpdatedAt.After(updated) {
+			updated = a.UpdatedAt
+		}
+		entry := atomEntry{
+			ID:        articleFeedID(base, a),
+			Title:     atomText{Type: "text", Body: a.Title},
+			Updated:   atomTime(a.UpdatedAt),
+			Published: atomTime(articleDate(a)),
+			Content:   atomText{Type: "text", Body: a.Content},
+		}
+		if a.Link != "" {
+			entry.Links = []atomLink{{Rel: "alternate", Href: a.Link}}
+		}
+		if a.Preview != "" {
+			entry.Summary = &atomText{Type: "text", Body: a.Preview}
+		}
+		for _, tag := range a.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag.Name})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+	feed.Updated = atomTime(updated)
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// GetAtomFeed publishes the latest articles as an Atom feed
+// @Summary      Latest articles as Atom
+// @Tags         articles
+// @Produce      xml
+// @Param        If-None-Match  header    string  false  "ETag of a previously fetched feed"
+// @Success      200            {string}  string  "Atom document"
+// @Success      304            "Feed unchanged"
+// @Router       /api/v1/feed.atom [get]
+func GetAtomFeed(c *gin.Context) {
+	serveFeed(c, atomMediaType, renderAtomFeed)
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url,omitempty"`
+	Title         string   `json:"title"`
+	ContentText   string   `json:"content_text"`
+	Summary       string   `json:"summary,omitempty"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+func renderJSONFeed(base string, articles []models.Article) ([]byte, error) {
+	title := config.AppConfig.Feed.Title
+	if title == "" {
+		title = "FinGOAT"
+	}
+	feed := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       title,
+		HomePageURL: base,
+		FeedURL:     base + jsonFeedPath,
+		Items:       make([]jsonFeedItem, 0, len(articles)),
+	}
+	for _, a := range articles {
+		item := jsonFeedItem{
+			ID:            articleFeedID(base, a),
+			URL:           a.Link,
+			Title:         a.Title,
+			ContentText:   a.Content,
+			Summary:       a.Preview,
+			DatePublished: atomTime(articleDate(a)),
+			DateModified:  atomTime(a.UpdatedAt),
+		}
+		for _, tag := range a.Tags {
+			item.Tags = append(item.Tags, tag.Name)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return json.MarshalIndent(feed, "", "  ")
+}
+
+// GetJSONFeed publishes the latest articles as a JSON Feed
+// @Summary      Latest articles as JSON Feed
+// @Tags         articles
+// @Produce      json
+// @Param        If-None-Match  header    string  false  "ETag of a previously fetched feed"
+// @Success      200            {object}  map[string]interface{}  "JSON Feed 1.1 document"
+// @Success      304            "Feed unchanged"
+// @Router       /api/v1/feed.json [get]
+func GetJSONFeed(c *gin.Context) {
+	serveFeed(c, jsonFeedMediaType, renderJSONFeed)
+}
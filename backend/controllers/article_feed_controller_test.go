@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+// parsedAtomFeed reads back the elements RFC 4287 requires of a feed and its
+// entries.
+type parsedAtomFeed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Entries []struct {
+		ID      string `xml:"id"`
+		Title   string `xml:"title"`
+		Updated string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+func getAtomFeed(t *testing.T) parsedAtomFeed {
+	t.Helper()
+	r := gin.New()
+	r.GET(atomFeedPath, GetAtomFeed)
+	w := testutil.Do(r, http.MethodGet, atomFeedPath, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Fatalf("Content-Type = %q, want application/atom+xml", ct)
+	}
+	var feed parsedAtomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("feed is not Atom XML: %v", err)
+	}
+	return feed
+}
+
+func checkAtomTime(t *testing.T, what, value string) {
+	t.Helper()
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		t.Errorf("%s updated = %q, want an RFC 3339 time", what, value)
+	}
+}
+
+func TestAtomFeed(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	published := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	articles := []models.Article{
+		{Title: "Imported", Content: "body", Preview: "body", Link: "https://example.com/a", PublishedAt: &published},
+		{Title: "Written here", Content: "body", Preview: "body"},
+	}
+	if err := db.Create(&articles).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	feed := getAtomFeed(t)
+	if feed.ID != "http://example.com"+atomFeedPath || feed.Title == "" {
+		t.Errorf("feed id %q, title %q", feed.ID, feed.Title)
+	}
+	checkAtomTime(t, "feed", feed.Updated)
+	if len(feed.Entries) != 2 {
+		t.Fatalf("%d entries, want 2", len(feed.Entries))
+	}
+	ids := map[string]string{}
+	for _, entry := range feed.Entries {
+		if entry.ID == "" || entry.Title == "" {
+			t.Errorf("entry id %q, title %q, want both set", entry.ID, entry.Title)
+		}
+		checkAtomTime(t, "entry", entry.Updated)
+		ids[entry.Title] = entry.ID
+	}
+	if ids["Imported"] != "https://example.com/a" {
+		t.Errorf("imported article's id = %q, want its original link", ids["Imported"])
+	}
+	if !strings.HasPrefix(ids["Written here"], "http://example.com/api/v1/articles/") {
+		t.Errorf("article's id = %q, want its API URL", ids["Written here"])
+	}
+}
+
+func TestAtomFeedEmpty(t *testing.T) {
+	testutil.Config(t)
+	testutil.DB(t)
+	testutil.Redis(t)
+
+	feed := getAtomFeed(t)
+	if feed.ID == "" || feed.Title == "" {
+		t.Errorf("feed id %q, title %q, want both set", feed.ID, feed.Title)
+	}
+	checkAtomTime(t, "empty feed", feed.Updated)
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxImportArticles caps a single import request.
+const maxImportArticles = 500
+
+// ArticleImportError reports an item rejected by validation.
+type ArticleImportError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ArticleImportResult summarizes an import. Skipped items have a Link that is
+// already stored (or repeated earlier in the same request), or, with
+// articles.dedup set to content, the same title and content as such an article.
+type ArticleImportResult struct {
+	Inserted int                  `json:"inserted"`
+	Skipped  int                  `json:"skipped"`
+	Errored  int                  `json:"errored"`
+	Errors   []ArticleImportError `json:"errors,omitempty"`
+}
+
+// ImportArticles inserts a JSON array of articles in one transaction.
+// Invalid items are reported by index and do not stop the others.
+// @Summary      Import articles
+// @Tags         articles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      []models.Article  true  "Articles (at most 500)"
+// @Success      200   {object}  ArticleImportResult
+// @Failure      400   {object}  map[string]string
+// @Router       /api/v1/articles/import [post]
+func ImportArticles(c *gin.Context) {
+	// Items are decoded and validated one by one: binding a []models.Article
+	// would validate every element and reject the whole import for one bad item
+	var items []json.RawMessage
+	if err := c.ShouldBindJSON(&items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no articles to import"})
+		return
+	}
+	if len(items) > maxImportArticles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many articles, at most 500 per request"})
+		return
+	}
+
+	var result ArticleImportResult
+	valid := make([]models.Article, 0, len(items))
+	for i, raw := range items {
+		var article models.Article
+		if err := json.Unmarshal(raw, &article); err != nil {
+			result.Errors = append(result.Errors, ArticleImportError{Index: i, Error: err.Error()})
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&article); err != nil {
+			result.Errors = append(result.Errors, ArticleImportError{Index: i, Error: validators.ErrorMessage(err)})
+			continue
+		}
+		// Client-supplied IDs and timestamps are ignored
+		article.Model = gorm.Model{}
+		setContentHash(&article)
+		valid = append(valid, article)
+	}
+	result.Errored = len(result.Errors)
+
+	if len(valid) > 0 {
+		err := global.DB.Transaction(func(tx *gorm.DB) error {
+			onConflict := clause.OnConflict{
+				Columns:     []clause.Column{{Name: "link"}},
+				TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "link <> ''"}}},
+				DoNothing:   true,
+			}
+			if dedupByContent() {
+				// Without a target, a duplicate link or content hash is skipped
+				onConflict = clause.OnConflict{DoNothing: true}
+			}
+			res := tx.Omit(clause.Associations).Clauses(onConflict).CreateInBatches(&valid, 100)
+			if res.Error != nil {
+				return res.Error
+			}
+			result.Inserted = int(res.RowsAffected)
+			return nil
+		})
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+		result.Skipped = len(valid) - result.Inserted
+	}
+
+	if result.Inserted > 0 {
+		invalidateArticlesCache(c.Request.Context())
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// dedupByContent reports whether articles.dedup asks for duplicates to be
+// detected by content as well as by link.
+func dedupByContent() bool {
+	return strings.EqualFold(config.AppConfig.Articles.Dedup, "content")
+}
+
+// setContentHash fills in an article's content hash when articles are
+// deduplicated by content, so the unique index rejects republished stories.
+func setContentHash(article *models.Article) {
+	article.ContentHash = nil
+	if dedupByContent() {
+		hash := models.ArticleContentHash(article.Title, article.Content)
+		article.ContentHash = &hash
+	}
+}
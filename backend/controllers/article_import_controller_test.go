@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func importArticles(t *testing.T, body string) ArticleImportResult {
+	t.Helper()
+	r := gin.New()
+	r.POST("/articles/import", ImportArticles)
+	w := testutil.Do(r, http.MethodPost, "/articles/import", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var result ArticleImportResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// The same story republished under another link, with different case and
+// spacing, is one article when deduplicating by content.
+const republishedArticles = `[
+	{"Title": "Rates hold", "Content": "The central bank held rates.", "Preview": "Rates", "Link": "https://example.com/a"},
+	{"Title": "rates  HOLD", "Content": "The central bank held  rates.", "Preview": "Rates", "Link": "https://example.org/b"}
+]`
+
+func TestImportArticlesDedupByContent(t *testing.T) {
+	testutil.Config(t).Articles.Dedup = "content"
+	db := testutil.DB(t)
+	testutil.Redis(t)
+
+	result := importArticles(t, republishedArticles)
+	if result.Inserted != 1 || result.Skipped != 1 || result.Errored != 0 {
+		t.Fatalf("result = %+v, want 1 inserted and 1 skipped", result)
+	}
+	var count int64
+	if err := db.Model(&models.Article{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("%d articles stored, want 1", count)
+	}
+
+	// Importing the story again skips it too
+	result = importArticles(t, `[{"Title": "Rates hold", "Content": "The central bank held rates.", "Preview": "Rates", "Link": "https://example.net/c"}]`)
+	if result.Inserted != 0 || result.Skipped != 1 {
+		t.Fatalf("reimport result = %+v, want it skipped", result)
+	}
+}
+
+func TestImportArticlesDedupByLinkKeepsRepublished(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+
+	result := importArticles(t, republishedArticles)
+	if result.Inserted != 2 || result.Skipped != 0 {
+		t.Fatalf("result = %+v, want both inserted", result)
+	}
+	var count int64
+	if err := db.Model(&models.Article{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("%d articles stored, want 2", count)
+	}
+}
+
+func TestImportArticlesReportsInvalidItemsByIndex(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+
+	result := importArticles(t, `[
+		{"Title": "First", "Content": "body", "Preview": "body"},
+		{"Title": "No content", "Preview": "body"},
+		{"Title": "Second", "Content": "body", "Preview": "body", "Link": "https://example.com/second"},
+		{"Title": 42, "Content": "body", "Preview": "body"},
+		{"Title": "Bad link", "Content": "body", "Preview": "body", "Link": "not a url"}
+	]`)
+	if result.Inserted != 2 || result.Skipped != 0 || result.Errored != 3 {
+		t.Fatalf("result = %+v, want 2 inserted and 3 errored", result)
+	}
+	var indexes []int
+	for _, e := range result.Errors {
+		indexes = append(indexes, e.Index)
+	}
+	if !slices.Equal(indexes, []int{1, 3, 4}) {
+		t.Fatalf("errors reported at %v, want [1 3 4]", indexes)
+	}
+	var titles []string
+	if err := db.Model(&models.Article{}).Order("id").Pluck("title", &titles).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(titles, []string{"First", "Second"}) {
+		t.Fatalf("stored %v, want the valid items", titles)
+	}
+}
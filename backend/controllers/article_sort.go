@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// articleSortColumns is the allowlist of sortable fields and the columns they
+// map to. Only these values ever reach ORDER BY.
+var articleSortColumns = map[string]string{
+	"published_at": "published_at",
+	"created_at":   "created_at",
+	"title":        "title",
+}
+
+// articleSort is a validated sort order for article listings.
+type articleSort struct {
+	Field string
+	Desc  bool
+}
+
+// defaultArticleSort is newest publication first.
+var defaultArticleSort = articleSort{Field: "published_at", Desc: true}
+
+// parseArticleSort reads the sort and order query parameters. An empty sort
+// selects the default; unknown fields or directions are rejected.
+func parseArticleSort(c *gin.Context) (articleSort, error) {
+	s := defaultArticleSort
+	if field := strings.ToLower(c.Query("sort")); field != "" {
+		if _, ok := articleSortColumns[field]; !ok {
+			return s, fmt.Errorf("invalid sort field %q: must be one of published_at, created_at, title", field)
+		}
+		s.Field = field
+	}
+	switch strings.ToLower(c.DefaultQuery("order", "desc")) {
+	case "desc":
+		s.Desc = true
+	case "asc":
+		s.Desc = false
+	default:
+		return s, fmt.Errorf("invalid order %q: must be asc or desc", c.Query("order"))
+	}
+	return s, nil
+}
+
+// orderClause returns the ORDER BY expression for the articles table. Missing
+// publication times sort last in either direction; ties are broken by id.
+func (s articleSort) orderClause() string {
+	dir := "ASC"
+	if s.Desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("articles.%s %s NULLS LAST, articles.id %s", articleSortColumns[s.Field], dir, dir)
+}
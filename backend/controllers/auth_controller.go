@@ -1,52 +1,131 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/JerryLinyx/FinGOAT/db"
 	"github.com/JerryLinyx/FinGOAT/global"
 	"github.com/JerryLinyx/FinGOAT/models"
 	"github.com/JerryLinyx/FinGOAT/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+type RegisterInput struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email" binding:"omitempty,email"`
+}
+
+type LoginInput struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// @Summary      Register a new user
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      RegisterInput  true  "Account details"
+// @Success      200   {object}  map[string]string  "token"
+// @Failure      400   {object}  map[string]interface{}
+// @Failure      409   {object}  map[string]string
+// @Router       /api/v1/auth/register [post]
 func Register(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var input RegisterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
 
-	hashedPassword, err := utils.HashPassword(user.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := utils.ValidatePassword(input.Password); err != nil {
+		c.JSON(http.StatusBadRequest, passwordPolicyResponse(err))
 		return
 	}
-	user.Password = hashedPassword
 
-	token, err := utils.GenerateJWT(user.Username)
+	var existing models.User
+	err := global.DB.Where("username = ?", input.Username).First(&existing).Error
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "username already taken"})
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		respondDBError(c, err)
+		return
+	}
+	if input.Email != "" {
+		err := global.DB.Where("email = ?", input.Email).First(&existing).Error
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "email already registered"})
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			respondDBError(c, err)
+			return
+		}
+	}
+
+	hashedPassword, err := utils.HashPassword(input.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	user := models.User{
+		Username: input.Username,
+		Password: hashedPassword,
+		Email:    input.Email,
+		Active:   true,
+	}
 
 	if err := global.DB.AutoMigrate(&user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondDBError(c, err)
 		return
 	}
 
 	if err := global.DB.Create(&user).Error; err != nil {
+		// A concurrent registration may have claimed the username or email after the checks above
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "username or email already taken"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		return
+	}
+
+	// The token carries the ID, so it can only be issued once the user exists
+	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if user.Email != "" {
+		sendVerificationEmail(c.Request.Context(), &user)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
 
+// @Summary      Log in and obtain a JWT
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      LoginInput  true  "Credentials"
+// @Success      200   {object}  map[string]string  "token"
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Router       /api/v1/auth/login [post]
 func Login(c *gin.Context) {
-	var input struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required"`
-	}
+	var input LoginInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -62,8 +141,12 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password"})
 		return
 	}
+	if !user.Active {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account is deactivated"})
+		return
+	}
 
-	token, err := utils.GenerateJWT(user.Username)
+	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -71,3 +154,74 @@ func Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
+
+// @Summary      Change the current user's password
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      ChangePasswordInput  true  "Current and new password"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]string
+// @Router       /api/v1/auth/change-password [post]
+func ChangePassword(c *gin.Context) {
+	var input ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := global.DB.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	if !utils.CheckPassword(input.CurrentPassword, user.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid current password"})
+		return
+	}
+	if input.NewPassword == input.CurrentPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new password must differ from the current password"})
+		return
+	}
+	if err := utils.ValidatePassword(input.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, passwordPolicyResponse(err))
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(input.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := global.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password updated successfully"})
+}
+
+// passwordPolicyResponse builds the 400 body for a password rejected by utils.ValidatePassword.
+func passwordPolicyResponse(err error) gin.H {
+	var policyErr *utils.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return gin.H{
+			"error":        "password does not meet requirements",
+			"requirements": policyErr.Unmet,
+		}
+	}
+	return gin.H{"error": err.Error()}
+}
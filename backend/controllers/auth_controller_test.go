@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/JerryLinyx/FinGOAT/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoginRejectsInactiveUser(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	hash, err := utils.HashPassword("Secr3t!pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{Username: "alice", Password: hash, Active: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.POST("/login", Login)
+	const body = `{"username":"alice","password":"Secr3t!pass"}`
+	if w := testutil.Do(r, http.MethodPost, "/login", body); w.Code != http.StatusOK {
+		t.Fatalf("active user: status %d, want 200: %s", w.Code, w.Body)
+	}
+
+	if err := db.Model(&user).Update("active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	w := testutil.Do(r, http.MethodPost, "/login", body)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("inactive user: status %d, want 403: %s", w.Code, w.Body)
+	}
+	// The password is checked first, so a wrong one doesn't reveal the account state
+	if w := testutil.Do(r, http.MethodPost, "/login", `{"username":"alice","password":"wrong"}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("inactive user with a wrong password: status %d, want 401", w.Code)
+	}
+}
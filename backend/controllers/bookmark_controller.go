@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/JerryLinyx/FinGOAT/db"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BookmarkArticle saves an article for the current user
+// @Summary      Bookmark an article
+// @Tags         bookmarks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Article ID"
+// @Success      201  {object}  models.Bookmark
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/articles/{id}/bookmark [post]
+func BookmarkArticle(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var article models.Article
+	if err := global.DB.First(&article, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "article not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	bookmark := models.Bookmark{UserID: userID.(uint), ArticleID: article.ID}
+	if err := global.DB.Omit("Article").Create(&bookmark).Error; err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "article already bookmarked"})
+			return
+		}
+		respondDBError(c, err)
+		return
+	}
+	bookmark.Article = article
+
+	c.JSON(http.StatusCreated, bookmark)
+}
+
+// RemoveBookmark removes an article from the current user's bookmarks
+// @Summary      Remove a bookmark
+// @Tags         bookmarks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  int  true  "Article ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/articles/{id}/bookmark [delete]
+func RemoveBookmark(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	result := global.DB.Where("user_id = ? AND article_id = ?", userID, c.Param("id")).Delete(&models.Bookmark{})
+	if result.Error != nil {
+		respondDBError(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "bookmark not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListBookmarks lists the current user's bookmarks with their articles, newest first.
+// Bookmarks of deleted articles are hidden.
+// @Summary      List bookmarked articles
+// @Tags         bookmarks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page       query     int  false  "Page (default 1)"
+// @Param        page_size  query     int  false  "Page size (default 20, max 100)"
+// @Success      200        {object}  map[string]interface{}
+// @Router       /api/v1/bookmarks [get]
+func ListBookmarks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	offset, limit := pagination.Parse(c)
+
+	query := global.DB.Model(&models.Bookmark{}).
+		Joins("JOIN articles ON articles.id = bookmarks.article_id AND articles.deleted_at IS NULL").
+		Where("bookmarks.user_id = ?", userID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	bookmarks := []models.Bookmark{}
+	if err := query.Session(&gorm.Session{}).
+		Preload("Article.Tags").
+		Order("bookmarks.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&bookmarks).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bookmarks":  bookmarks,
+		"pagination": pagination.NewMeta(offset, limit, total),
+	})
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CommentInput struct {
+	Body string `json:"body" binding:"required,max=2000"`
+}
+
+// CommentResponse is a comment with its author's username.
+type CommentResponse struct {
+	ID        uint      `json:"id"`
+	ArticleID uint      `json:"article_id"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newCommentResponse(comment *models.Comment) CommentResponse {
+	return CommentResponse{
+		ID:        comment.ID,
+		ArticleID: comment.ArticleID,
+		UserID:    comment.UserID,
+		Username:  comment.User.Username,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+// CreateComment adds a comment to an article
+// @Summary      Comment on an article
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int           true  "Article ID"
+// @Param        body  body      CommentInput  true  "Comment"
+// @Success      201   {object}  CommentResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Router       /api/v1/articles/{id}/comments [post]
+func CreateComment(c *gin.Context) {
+	var input CommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body := strings.TrimSpace(input.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "comment body is empty"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var article models.Article
+	if err := global.DB.First(&article, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "article not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	comment := models.Comment{ArticleID: article.ID, UserID: userID.(uint), Body: body}
+	if err := global.DB.Omit("User").Create(&comment).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	comment.User.Username = c.GetString("username")
+
+	c.JSON(http.StatusCreated, newCommentResponse(&comment))
+}
+
+// ListComments lists an article's comments, newest first
+// @Summary      List an article's comments
+// @Tags         comments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      int  true   "Article ID"
+// @Param        page       query     int  false  "Page (default 1)"
+// @Param        page_size  query     int  false  "Page size (default 20, max 100)"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      404        {object}  map[string]string
+// @Router       /api/v1/articles/{id}/comments [get]
+func ListComments(c *gin.Context) {
+	var article models.Article
+	if err := global.DB.First(&article, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "article not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	offset, limit := pagination.Parse(c)
+
+	query := global.DB.Model(&models.Comment{}).Where("article_id = ?", article.ID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	var comments []models.Comment
+	if err := query.Session(&gorm.Session{}).
+		Preload("User").
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&comments).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	responses := make([]CommentResponse, len(comments))
+	for i := range comments {
+		responses[i] = newCommentResponse(&comments[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments":   responses,
+		"pagination": pagination.NewMeta(offset, limit, total),
+	})
+}
+
+// DeleteComment soft-deletes a comment. Only its author or an admin may delete it.
+// @Summary      Delete a comment
+// @Tags         comments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  int  true  "Comment ID"
+// @Success      204
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/comments/{id} [delete]
+func DeleteComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var comment models.Comment
+	if err := global.DB.First(&comment, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	if comment.UserID != userID.(uint) && c.GetString("role") != middlewares.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to delete this comment"})
+		return
+	}
+
+	if err := global.DB.Delete(&comment).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
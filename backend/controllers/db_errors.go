@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/JerryLinyx/FinGOAT/db"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/gin-gonic/gin"
+)
+
+// respondDBError writes the response db.StatusForError gives for a failed
+// database call. Unexpected errors are logged, since clients only see a
+// generic message.
+func respondDBError(c *gin.Context, err error) {
+	status, message := db.StatusForError(err)
+	if status >= 500 {
+		global.Logger.Error("Database error", "path", c.FullPath(), "request_id", c.GetString("request_id"), "error", err)
+	}
+	c.JSON(status, gin.H{"error": message})
+}
+
+// dbAnalysisError is respondDBError for analysis submission, which reports
+// failures as an analysisError rather than writing a response.
+func dbAnalysisError(err error) *analysisError {
+	status, message := db.StatusForError(err)
+	if status >= 500 {
+		global.Logger.Error("Database error", "error", err)
+	}
+	return &analysisError{status, message}
+}
+
+// respondRedisError answers a request that could not be served because a Redis
+// call failed. Like respondDBError it logs the error and sends a generic message.
+func respondRedisError(c *gin.Context, err error) {
+	global.Logger.Error("Redis error", "path", c.FullPath(), "request_id", c.GetString("request_id"), "error", err)
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "service temporarily unavailable, retry later"})
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const emailVerifyKeyPrefix = "email_verify:"
+
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// sendVerificationEmail issues a verification token for the user's email and
+// mails the link. Failures are logged; registration does not depend on them.
+func sendVerificationEmail(ctx context.Context, user *models.User) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		global.Logger.Error("Failed to generate email verification token", "error", err)
+		return
+	}
+	token := hex.EncodeToString(b)
+
+	ttl := config.AppConfig.Email.VerificationTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	// Bound to the address, so a link stops working once the email is changed
+	value := strconv.FormatUint(uint64(user.ID), 10) + ":" + user.Email
+	if err := global.RedisDB.Set(ctx, emailVerifyKeyPrefix+token, value, ttl).Err(); err != nil {
+		global.Logger.Error("Failed to store email verification token", "user_id", user.ID, "error", err)
+		return
+	}
+
+	link := config.AppConfig.Email.VerifyURL + "?token=" + url.QueryEscape(token)
+	body := "Welcome to FinGOAT, " + user.Username + "!\n\n" +
+		"Please confirm your email address by opening the link below:\n\n" + link + "\n\n" +
+		"The link expires in " + ttl.String() + "."
+
+	go func() {
+		if err := utils.SendMail(user.Email, "Verify your FinGOAT email", body); err != nil {
+			if errors.Is(err, utils.ErrMailDisabled) {
+				global.Logger.Debug("Email sending disabled, skipping verification email", "user_id", user.ID)
+				return
+			}
+			global.Logger.Error("Failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}()
+}
+
+// @Summary      Verify an email address
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      VerifyEmailInput  true  "Verification token"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Router       /api/v1/auth/verify-email [post]
+func VerifyEmail(c *gin.Context) {
+	var input VerifyEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	key := emailVerifyKeyPrefix + input.Token
+	value, err := global.RedisDB.Get(ctx, key).Result()
+	if err == redis.Nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+		return
+	} else if err != nil {
+		respondRedisError(c, err)
+		return
+	}
+
+	// The token holds "<user ID>:<email>"; it only verifies that address
+	idPart, email, bound := strings.Cut(value, ":")
+	userID, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil || !bound || email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+		return
+	}
+
+	result := global.DB.Model(&models.User{}).Where("id = ? AND email = ?", userID, email).
+		Update("email_verified", true)
+	if result.Error != nil {
+		respondDBError(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+		return
+	}
+
+	_ = global.RedisDB.Del(ctx, key).Err()
+
+	var user models.User
+	if err := global.DB.Select("username").First(&user, userID).Error; err == nil {
+		middlewares.InvalidateAuthUser(ctx, user.Username)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified successfully"})
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	user := models.User{Username: "alice", Password: "hash", Email: "alice@example.com", Active: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.POST("/verify-email", VerifyEmail)
+
+	tests := []struct {
+		name   string
+		value  string
+		status int
+	}{
+		{"token without an email", fmt.Sprint(user.ID), http.StatusBadRequest},
+		{"token for an earlier email", fmt.Sprint(user.ID, ":old@example.com"), http.StatusBadRequest},
+		{"token with an empty email", fmt.Sprint(user.ID, ":"), http.StatusBadRequest},
+		{"token for the current email", fmt.Sprint(user.ID, ":alice@example.com"), http.StatusOK},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := fmt.Sprint("token-", i)
+			if err := global.RedisDB.Set(context.Background(), emailVerifyKeyPrefix+token, tt.value, 0).Err(); err != nil {
+				t.Fatal(err)
+			}
+			w := testutil.Do(r, http.MethodPost, "/verify-email", `{"token":"`+token+`"}`)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			var stored models.User
+			if err := db.First(&stored, user.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored.EmailVerified != (tt.status == http.StatusOK) {
+				t.Fatalf("email verified = %v after a %d", stored.EmailVerified, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// maxBatchExchangeRates caps a single batch request.
+const maxBatchExchangeRates = 1000
+
+// ExchangeRateInput is one rate in a batch. Date defaults to now.
+type ExchangeRateInput struct {
+	FromCurrency string     `json:"fromCurrency" binding:"required,currency"`
+	ToCurrency   string     `json:"toCurrency" binding:"required,currency"`
+	Rate         float64    `json:"rate" binding:"required,gt=0"`
+	Date         *time.Time `json:"date"`
+}
+
+// ExchangeRateBatchError reports an item rejected by validation.
+type ExchangeRateBatchError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ExchangeRateBatchResult summarizes a batch. As with a single POST, a rate
+// for a pair and UTC day that is already stored updates it, or leaves it
+// unchanged when the rate is the same.
+type ExchangeRateBatchResult struct {
+	Created   int                      `json:"created"`
+	Updated   int                      `json:"updated"`
+	Unchanged int                      `json:"unchanged"`
+	Errored   int                      `json:"errored"`
+	Errors    []ExchangeRateBatchError `json:"errors,omitempty"`
+}
+
+// CreateExchangeRatesBatch upserts a JSON array of rates in one transaction.
+// Invalid items are reported by index and do not stop the others.
+// @Summary      Record exchange rates in bulk
+// @Tags         exchange-rates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      []ExchangeRateInput  true  "Exchange rates (at most 1000)"
+// @Success      200   {object}  ExchangeRateBatchResult
+// @Failure      400   {object}  map[string]string
+// @Router       /api/v1/exchangeRates/batch [post]
+func CreateExchangeRatesBatch(c *gin.Context) {
+	// Items are decoded one by one so that a malformed item, such as a rate
+	// given as a string, is reported without rejecting the whole batch
+	var items []json.RawMessage
+	if err := c.ShouldBindJSON(&items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no exchange rates to record"})
+		return
+	}
+	if len(items) > maxBatchExchangeRates {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many exchange rates, at most 1000 per request"})
+		return
+	}
+
+	var result ExchangeRateBatchResult
+	now := time.Now().UTC()
+	valid := make([]models.ExchangeRate, 0, len(items))
+	for i, raw := range items {
+		var input ExchangeRateInput
+		if err := json.Unmarshal(raw, &input); err != nil {
+			result.Errors = append(result.Errors, ExchangeRateBatchError{Index: i, Error: err.Error()})
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&input); err != nil {
+			result.Errors = append(result.Errors, ExchangeRateBatchError{Index: i, Error: validators.ErrorMessage(err)})
+			continue
+		}
+		rate := models.ExchangeRate{
+			FromCurrency: strings.ToUpper(input.FromCurrency),
+			ToCurrency:   strings.ToUpper(input.ToCurrency),
+			Rate:         input.Rate,
+			Date:         now,
+		}
+		if input.Date != nil {
+			rate.Date = *input.Date
+		}
+		valid = append(valid, rate)
+	}
+	result.Errored = len(result.Errors)
+
+	written := make([]models.ExchangeRate, 0, len(valid))
+	if len(valid) > 0 {
+		err := global.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			for i := range valid {
+				outcome, err := saveDailyRate(tx, &valid[i])
+				if err != nil {
+					return err
+				}
+				switch outcome {
+				case rateCreated:
+					result.Created++
+				case rateUpdated:
+					result.Updated++
+				default:
+					result.Unchanged++
+				}
+				if outcome != rateUnchanged {
+					written = append(written, valid[i])
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			respondDBError(c, err)
+			return
+		}
+	}
+
+	if len(written) > 0 {
+		go func() {
+			ctx := context.Background()
+			InvalidateExchangeRatesCache(ctx)
+			for _, rate := range written {
+				announceRate(ctx, rate)
+			}
+		}()
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -1,47 +1,344 @@
 package controllers
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/JerryLinyx/FinGOAT/cache"
+	"github.com/JerryLinyx/FinGOAT/db"
 	"github.com/JerryLinyx/FinGOAT/global"
 	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/pagination"
+	"github.com/JerryLinyx/FinGOAT/utils"
+	"github.com/JerryLinyx/FinGOAT/validators"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
 )
 
+// exchangeRatesCacheKey prefixes the cached pages of the exchange rate list.
+// Keys carry the version counter at exchangeRatesVersionKey, so a write retires
+// every cached page at once.
+var exchangeRatesCacheKey = "exchangeRates"
+
+const (
+	exchangeRatesVersionKey = "exchangeRates:version"
+
+	latestRateKeyPrefix = "exchangeRates:latest:"
+	// latestRateTTL bounds staleness for writes that do not invalidate the
+	// entry, such as rows changed directly in the database.
+	latestRateTTL = time.Minute
+)
+
+// exchangeRatesFlight coalesces concurrent cache misses for the exchange rate list.
+var exchangeRatesFlight singleflight.Group
+
+// exchangeRatesPage is one cached page of the unfiltered exchange rate list.
+type exchangeRatesPage struct {
+	ExchangeRates []models.ExchangeRate `json:"exchange_rates"`
+	Total         int64                 `json:"total"`
+}
+
+// @Summary      Record an exchange rate
+// @Description  Stores the rate for its currency pair and UTC day. Posting a pair again on the same day updates that day's rate instead of adding a row. The date defaults to now.
+// @Tags         exchange-rates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      models.ExchangeRate  true  "Exchange rate"
+// @Success      200   {object}  models.ExchangeRate  "Existing rate for the day updated"
+// @Success      201   {object}  models.ExchangeRate  "New rate for the day created"
+// @Failure      400   {object}  map[string]string
+// @Router       /api/v1/exchangeRates [post]
 func CreateExchangeRate(c *gin.Context) {
 	var exchangeRate models.ExchangeRate
 	if err := c.ShouldBindJSON(&exchangeRate); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	exchangeRate.Date = time.Now()
+	exchangeRate.ID = 0
+	exchangeRate.FromCurrency = strings.ToUpper(strings.TrimSpace(exchangeRate.FromCurrency))
+	exchangeRate.ToCurrency = strings.ToUpper(strings.TrimSpace(exchangeRate.ToCurrency))
+	if exchangeRate.Date.IsZero() {
+		exchangeRate.Date = time.Now()
+	}
 
 	if err := global.DB.AutoMigrate(&exchangeRate); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondDBError(c, err)
 		return
 	}
 
-	if err := global.DB.Create(&exchangeRate).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	result, err := saveDailyRate(global.DB.WithContext(c.Request.Context()), &exchangeRate)
+	if err != nil {
+		respondDBError(c, err)
 		return
 	}
+	if result != rateUnchanged {
+		go func(rate models.ExchangeRate) {
+			ctx := context.Background()
+			InvalidateExchangeRatesCache(ctx)
+			announceRate(ctx, rate)
+		}(exchangeRate)
+	}
 
-	c.JSON(http.StatusCreated, exchangeRate)
+	if result == rateCreated {
+		c.JSON(http.StatusCreated, exchangeRate)
+		return
+	}
+	c.JSON(http.StatusOK, exchangeRate)
 }
 
+// ExchangeRateQuery filters the exchange rate listing. Dates are inclusive.
+type ExchangeRateQuery struct {
+	Base      string `form:"base" binding:"omitempty,currency"`
+	Quote     string `form:"quote" binding:"omitempty,currency"`
+	StartDate string `form:"start_date" binding:"omitempty,isodate"`
+	EndDate   string `form:"end_date" binding:"omitempty,isodate"`
+}
+
+func (q ExchangeRateQuery) filtered() bool {
+	return q.Base != "" || q.Quote != "" || q.StartDate != "" || q.EndDate != ""
+}
+
+// @Summary      List exchange rates
+// @Tags         exchange-rates
+// @Produce      json
+// @Param        base        query     string  false  "Base currency code, e.g. USD"
+// @Param        quote       query     string  false  "Quote currency code, e.g. EUR"
+// @Param        start_date  query     string  false  "Earliest date (YYYY-MM-DD)"
+// @Param        end_date    query     string  false  "Latest date (YYYY-MM-DD)"
+// @Param        page        query     int     false  "Page (default 1)"
+// @Param        page_size   query     int     false  "Page size (default 20, max 100)"
+// @Success      200         {object}  map[string]interface{}
+// @Failure      400         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /api/v1/exchangeRates [get]
 func GetExchangeRates(c *gin.Context) {
+	var filter ExchangeRateQuery
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validators.ErrorMessage(err)})
+		return
+	}
+	// Both are YYYY-MM-DD, so they compare as strings
+	if filter.StartDate != "" && filter.EndDate != "" && filter.StartDate > filter.EndDate {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must not be after end_date"})
+		return
+	}
+
 	var exchangeRates []models.ExchangeRate
-	if err := global.DB.Find(&exchangeRates).Error; err != nil {
+	ctx := c.Request.Context()
+	offset, limit := pagination.Parse(c)
+
+	// Filtered listings are not cached
+	if filter.filtered() {
+		query := global.DB.WithContext(ctx).Model(&models.ExchangeRate{})
+		if filter.Base != "" {
+			query = query.Where("from_currency = ?", strings.ToUpper(filter.Base))
+		}
+		if filter.Quote != "" {
+			query = query.Where("to_currency = ?", strings.ToUpper(filter.Quote))
+		}
+		if filter.StartDate != "" {
+			start, _ := time.Parse(validators.DateLayout, filter.StartDate)
+			query = query.Where("date >= ?", start)
+		}
+		if filter.EndDate != "" {
+			end, _ := time.Parse(validators.DateLayout, filter.EndDate)
+			query = query.Where("date < ?", end.AddDate(0, 0, 1))
+		}
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if err := query.Session(&gorm.Session{}).
+			Order("date, id").
+			Offset(offset).
+			Limit(limit).
+			Find(&exchangeRates).Error; err != nil {
+			respondDBError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"exchange_rates": exchangeRates,
+			"pagination":     pagination.NewMeta(offset, limit, total),
+		})
+		return
+	}
+
+	key := fmt.Sprintf("%s:%s:%d:%d", exchangeRatesCacheKey, cacheVersion(ctx, exchangeRatesVersionKey), offset, limit)
+	page, err := cachedLoad(ctx, &exchangeRatesFlight, key, func(ctx context.Context) (exchangeRatesPage, error) {
+		return loadExchangeRatesPage(ctx, offset, limit)
+	})
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange_rates": page.ExchangeRates,
+		"pagination":     pagination.NewMeta(offset, limit, page.Total),
+	})
+}
+
+// LatestRateQuery names the currency pair for GetLatestExchangeRate.
+type LatestRateQuery struct {
+	Base  string `form:"base" binding:"required,currency"`
+	Quote string `form:"quote" binding:"required,currency"`
+}
+
+// GetLatestExchangeRate returns the newest rate recorded for a currency pair
+// @Summary      Get the latest rate for a currency pair
+// @Tags         exchange-rates
+// @Produce      json
+// @Param        base   query     string  true  "Base currency code, e.g. USD"
+// @Param        quote  query     string  true  "Quote currency code, e.g. EUR"
+// @Success      200    {object}  models.ExchangeRate
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /api/v1/exchangeRates/latest [get]
+func GetLatestExchangeRate(c *gin.Context) {
+	var pair LatestRateQuery
+	if err := c.ShouldBindQuery(&pair); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validators.ErrorMessage(err)})
+		return
+	}
+	base, quote := strings.ToUpper(pair.Base), strings.ToUpper(pair.Quote)
+
+	ctx := c.Request.Context()
+	key := latestRateKey(base, quote)
+	var rate models.ExchangeRate
+	if hit, _ := cache.GetJSON(ctx, key, &rate); hit {
+		c.JSON(http.StatusOK, rate)
+		return
+	}
+
+	if err := global.DB.WithContext(ctx).
+		Where("from_currency = ? AND to_currency = ?", base, quote).
+		Order("date desc").
+		First(&rate).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			c.JSON(http.StatusNotFound, gin.H{"error": "no exchange rate for " + base + "/" + quote})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondDBError(c, err)
 		}
 		return
 	}
-	c.JSON(http.StatusOK, exchangeRates)
+	_ = cache.SetJSON(ctx, key, rate, latestRateTTL)
+
+	c.JSON(http.StatusOK, rate)
+}
+
+func latestRateKey(base, quote string) string {
+	return latestRateKeyPrefix + base + ":" + quote
+}
+
+// invalidateLatestRate drops the cached latest rate of a currency pair.
+func invalidateLatestRate(ctx context.Context, base, quote string) {
+	utils.CacheDel(ctx, latestRateKey(strings.ToUpper(base), strings.ToUpper(quote)))
+}
+
+// loadExchangeRatesPage reads one page of the unfiltered list and the total
+// number of exchange rates.
+func loadExchangeRatesPage(ctx context.Context, offset, limit int) (exchangeRatesPage, error) {
+	page := exchangeRatesPage{ExchangeRates: []models.ExchangeRate{}}
+	query := global.DB.WithContext(ctx).Model(&models.ExchangeRate{})
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	err := query.Session(&gorm.Session{}).
+		Order("date, id").
+		Offset(offset).
+		Limit(limit).
+		Find(&page.ExchangeRates).Error
+	return page, err
+}
+
+// InvalidateExchangeRatesCache bumps the list's version, retiring the cached
+// pages; they expire with their TTL. A load that started before the write can only
+// fill the old key, so it is never served again.
+func InvalidateExchangeRatesCache(ctx context.Context) {
+	utils.CacheIncr(ctx, exchangeRatesVersionKey)
+}
+
+// UpsertDailyExchangeRate stores a rate for its currency pair and UTC day. If a
+// row already exists for that pair on the same day it is updated in place, and
+// left untouched when the rate is unchanged. It reports whether anything was
+// written; written rates are also published to websocket subscribers.
+func UpsertDailyExchangeRate(ctx context.Context, rate *models.ExchangeRate) (bool, error) {
+	result, err := saveDailyRate(global.DB.WithContext(ctx), rate)
+	if err != nil || result == rateUnchanged {
+		return false, err
+	}
+	announceRate(ctx, *rate)
+	return true, nil
+}
+
+// rateWrite is the outcome of saveDailyRate.
+type rateWrite int
+
+const (
+	rateUnchanged rateWrite = iota
+	rateCreated
+	rateUpdated
+)
+
+// saveDailyRate upserts rate on (pair, UTC day) using conn, which may be a
+// transaction, and leaves rate holding the stored row. Caches and subscribers
+// are left to the caller; see announceRate.
+func saveDailyRate(conn *gorm.DB, rate *models.ExchangeRate) (rateWrite, error) {
+	rate.Date = rate.Date.UTC()
+	rate.Day = rate.Date.Format(validators.DateLayout)
+
+	var existing models.ExchangeRate
+	err := findDailyRate(conn, rate, &existing)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Nested so that, inside a transaction, a failed insert only rolls
+		// back to a savepoint instead of aborting the whole transaction
+		err = conn.Transaction(func(tx *gorm.DB) error {
+			return tx.Create(rate).Error
+		})
+		if err == nil {
+			return rateCreated, nil
+		}
+		if !db.IsUniqueViolation(err) {
+			return rateUnchanged, err
+		}
+		// A concurrent write stored the day's rate first; update that row instead
+		rate.ID = 0
+		err = findDailyRate(conn, rate, &existing)
+	}
+	if err != nil {
+		return rateUnchanged, err
+	}
+
+	if existing.Rate == rate.Rate {
+		*rate = existing
+		return rateUnchanged, nil
+	}
+	if err := conn.Model(&existing).
+		Updates(map[string]interface{}{"rate": rate.Rate, "date": rate.Date}).Error; err != nil {
+		return rateUnchanged, err
+	}
+	existing.Rate, existing.Date = rate.Rate, rate.Date
+	*rate = existing
+	return rateUpdated, nil
+}
+
+func findDailyRate(conn *gorm.DB, rate *models.ExchangeRate, dest *models.ExchangeRate) error {
+	return conn.Where("from_currency = ? AND to_currency = ? AND day = ?", rate.FromCurrency, rate.ToCurrency, rate.Day).
+		First(dest).Error
+}
+
+// announceRate drops the cached latest rate of a written rate's pair and
+// publishes it to websocket subscribers. The list cache is left to the caller,
+// which may have written several rates.
+func announceRate(ctx context.Context, rate models.ExchangeRate) {
+	invalidateLatestRate(ctx, rate.FromCurrency, rate.ToCurrency)
+	PublishExchangeRate(ctx, rate)
 }
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateExchangeRateStoresOneRatePerDay(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+
+	r := gin.New()
+	r.POST("/exchangeRates", CreateExchangeRate)
+	post := func(body string) int {
+		return testutil.Do(r, http.MethodPost, "/exchangeRates", body).Code
+	}
+	const rate = `{"fromCurrency":"usd","toCurrency":"EUR","rate":0.9,"date":"2024-01-02T09:00:00Z"}`
+
+	if code := post(rate); code != http.StatusCreated {
+		t.Fatalf("first post: status %d, want 201", code)
+	}
+	if code := post(rate); code != http.StatusOK {
+		t.Fatalf("same rate again: status %d, want 200", code)
+	}
+	// Later the same day, with a new rate
+	if code := post(`{"fromCurrency":"USD","toCurrency":"EUR","rate":0.91,"date":"2024-01-02T17:00:00Z"}`); code != http.StatusOK {
+		t.Fatalf("updated rate: status %d, want 200", code)
+	}
+
+	var rates []models.ExchangeRate
+	if err := db.Find(&rates).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 1 || rates[0].Rate != 0.91 {
+		t.Fatalf("rates = %+v, want one row with the latest rate", rates)
+	}
+}
+
+func TestCreateExchangeRateConcurrentPosts(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+
+	r := gin.New()
+	r.POST("/exchangeRates", CreateExchangeRate)
+	codes := make([]int, 10)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = testutil.Do(r, http.MethodPost, "/exchangeRates",
+				`{"fromCurrency":"USD","toCurrency":"JPY","rate":150,"date":"2024-01-02T00:00:00Z"}`).Code
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for i, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusOK:
+		default:
+			t.Fatalf("post %d: status %d, want 200 or 201", i, code)
+		}
+	}
+	var rows int64
+	db.Model(&models.ExchangeRate{}).Count(&rows)
+	if rows != 1 || created != 1 {
+		t.Fatalf("%d rows and %d creations from 10 concurrent posts, want 1 and 1", rows, created)
+	}
+}
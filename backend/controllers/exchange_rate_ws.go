@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// ExchangeRateUpdatesChannel is the Redis channel rate updates are published
+// on, so that subscribers connected to any replica receive them.
+const ExchangeRateUpdatesChannel = "exchangeRates:updates"
+
+const (
+	// rateSendBuffer bounds the updates queued for one subscriber; one that
+	// falls further behind is disconnected.
+	rateSendBuffer = 16
+	rateWriteWait  = 10 * time.Second
+	ratePongWait   = 60 * time.Second
+	ratePingPeriod = ratePongWait * 9 / 10
+)
+
+var rateUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     checkRateOrigin,
+}
+
+// rateSubscriber is one websocket client of the rate stream.
+type rateSubscriber struct {
+	send  chan models.ExchangeRate
+	pairs map[string]bool // "BASE/QUOTE"; empty subscribes to every pair
+}
+
+func (s *rateSubscriber) wants(rate *models.ExchangeRate) bool {
+	return len(s.pairs) == 0 || s.pairs[ratePair(rate.FromCurrency, rate.ToCurrency)]
+}
+
+// rateHub tracks the rate subscribers connected to this replica.
+type rateHub struct {
+	mu   sync.Mutex
+	subs map[*rateSubscriber]struct{}
+}
+
+var rateSubscribers = &rateHub{subs: map[*rateSubscriber]struct{}{}}
+
+func (h *rateHub) add(s *rateSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = struct{}{}
+}
+
+// remove unregisters s and closes its send channel, which ends its writer.
+// It is safe to call more than once.
+func (h *rateHub) remove(s *rateSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.send)
+	}
+}
+
+func (h *rateHub) broadcast(rate models.ExchangeRate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if !s.wants(&rate) {
+			continue
+		}
+		select {
+		case s.send <- rate:
+		default:
+			// Too slow to keep up; drop it rather than block everyone else
+			delete(h.subs, s)
+			close(s.send)
+		}
+	}
+}
+
+// PublishExchangeRate announces a new or changed rate to websocket
+// subscribers on every replica. If Redis is unavailable, only this replica's
+// subscribers are notified.
+func PublishExchangeRate(ctx context.Context, rate models.ExchangeRate) {
+	data, err := json.Marshal(rate)
+	if err == nil {
+		err = global.RedisDB.Publish(ctx, ExchangeRateUpdatesChannel, data).Err()
+	}
+	if err != nil {
+		global.Logger.Warn("Failed to publish exchange rate update", "error", err)
+		rateSubscribers.broadcast(rate)
+	}
+}
+
+// BroadcastExchangeRate sends a rate published on ExchangeRateUpdatesChannel
+// to the matching subscribers connected to this replica.
+func BroadcastExchangeRate(rate models.ExchangeRate) {
+	rateSubscribers.broadcast(rate)
+}
+
+// CloseRateSubscribers disconnects every subscriber, for shutdown.
+func CloseRateSubscribers() {
+	rateSubscribers.mu.Lock()
+	defer rateSubscribers.mu.Unlock()
+	for s := range rateSubscribers.subs {
+		delete(rateSubscribers.subs, s)
+		close(s.send)
+	}
+}
+
+// StreamExchangeRates upgrades to a websocket that receives each new or changed
+// exchange rate as a models.ExchangeRate JSON message
+// @Summary      Stream exchange rate updates over a websocket
+// @Tags         exchange-rates
+// @Param        pairs  query  string  false  "Comma-separated pairs such as USD/EUR,USD/JPY; all pairs when omitted"
+// @Success      101
+// @Failure      400    {object}  map[string]string
+// @Router       /api/v1/exchangeRates/ws [get]
+func StreamExchangeRates(c *gin.Context) {
+	pairs := map[string]bool{}
+	if raw := c.Query("pairs"); raw != "" {
+		for _, pair := range strings.Split(raw, ",") {
+			base, quote, ok := strings.Cut(strings.TrimSpace(pair), "/")
+			if !ok || !validators.IsCurrencyCode(base) || !validators.IsCurrencyCode(quote) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "pairs must be comma-separated currency pairs such as USD/EUR"})
+				return
+			}
+			pairs[ratePair(base, quote)] = true
+		}
+	}
+
+	conn, err := rateUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written the error response
+		return
+	}
+
+	sub := &rateSubscriber{send: make(chan models.ExchangeRate, rateSendBuffer), pairs: pairs}
+	rateSubscribers.add(sub)
+	go writeRates(conn, sub)
+
+	// Clients only send control frames; reading processes them and notices
+	// when the connection goes away
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(ratePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(ratePongWait))
+	})
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	rateSubscribers.remove(sub)
+}
+
+// writeRates sends queued updates and keepalive pings until the subscriber is
+// removed or a write fails, then closes the connection.
+func writeRates(conn *websocket.Conn, sub *rateSubscriber) {
+	ticker := time.NewTicker(ratePingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case rate, ok := <-sub.send:
+			_ = conn.SetWriteDeadline(time.Now().Add(rateWriteWait))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := conn.WriteJSON(rate); err != nil {
+				rateSubscribers.remove(sub)
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(rateWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				rateSubscribers.remove(sub)
+				return
+			}
+		}
+	}
+}
+
+func ratePair(base, quote string) string {
+	return strings.ToUpper(base) + "/" + strings.ToUpper(quote)
+}
+
+// checkRateOrigin accepts browser connections from the origins allowed by
+// cors.allowedOrigins, or from the API's own host when none are configured.
+// Clients that send no Origin header are not browsers and are always accepted.
+func checkRateOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	allowed := config.AppConfig.CORS.AllowedOrigins
+	if len(allowed) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	for _, o := range allowed {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
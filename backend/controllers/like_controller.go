@@ -8,18 +8,32 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+// @Summary      Like an article
+// @Tags         articles
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Article ID"
+// @Success      200  {object}  map[string]string
+// @Router       /api/v1/articles/{id}/like [post]
 func LikeArticle(c *gin.Context) {
 	articleID := c.Param("id")
 
 	likeKey := "article:" + articleID + ":likes"
 
 	if err := global.RedisDB.Incr(c, likeKey).Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondRedisError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Article liked successfully"})
 }
 
+// @Summary      Get an article's like count
+// @Tags         articles
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Article ID"
+// @Success      200  {object}  map[string]string  "likes"
+// @Router       /api/v1/articles/{id}/like [get]
 func GetArticleLikes(c *gin.Context) {
 	articleID := c.Param("id")
 
@@ -29,7 +43,7 @@ func GetArticleLikes(c *gin.Context) {
 	if err == redis.Nil {
 		likes = "0"
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondRedisError(c, err)
 		return
 	}
 
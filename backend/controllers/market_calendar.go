@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/validators"
+)
+
+// Values of trading.nonTradingDays, what to do with an analysis requested
+// for a weekend or market holiday.
+const (
+	nonTradingDaysAllow    = "allow"    // submit it anyway
+	nonTradingDaysReject   = "reject"   // answer 400
+	nonTradingDaysPrevious = "previous" // analyse the previous trading day instead
+)
+
+// usMarketHolidays are the full-day NYSE closures, used when
+// calendar.holidays is not configured.
+var usMarketHolidays = []string{
+	"2024-01-01", "2024-01-15", "2024-02-19", "2024-03-29", "2024-05-27",
+	"2024-06-19", "2024-07-04", "2024-09-02", "2024-11-28", "2024-12-25",
+	"2025-01-01", "2025-01-09", "2025-01-20", "2025-02-17", "2025-04-18",
+	"2025-05-26", "2025-06-19", "2025-07-04", "2025-09-01", "2025-11-27",
+	"2025-12-25",
+	"2026-01-01", "2026-01-19", "2026-02-16", "2026-04-03", "2026-05-25",
+	"2026-06-19", "2026-07-03", "2026-09-07", "2026-11-26", "2026-12-25",
+	"2027-01-01", "2027-01-18", "2027-02-15", "2027-03-26", "2027-05-31",
+	"2027-06-18", "2027-07-05", "2027-09-06", "2027-11-25", "2027-12-24",
+}
+
+// marketHolidays returns calendar.holidays, or the bundled US list when it is
+// empty.
+func marketHolidays() []string {
+	if holidays := config.AppConfig.Calendar.Holidays; len(holidays) > 0 {
+		return holidays
+	}
+	return usMarketHolidays
+}
+
+// isTradingDay reports whether day is neither a weekend nor a market holiday.
+func isTradingDay(day time.Time) bool {
+	if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	return !slices.Contains(marketHolidays(), day.Format(validators.DateLayout))
+}
+
+// previousTradingDay returns the latest trading day on or before day. The
+// search gives up after a month so a calendar listing every day cannot hang it.
+func previousTradingDay(day time.Time) time.Time {
+	for i := 0; i < 31 && !isTradingDay(day); i++ {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+func nonTradingDaysMode() string {
+	mode := strings.ToLower(config.AppConfig.Trading.NonTradingDays)
+	if mode == "" {
+		return nonTradingDaysAllow
+	}
+	return mode
+}
+
+// checkMarketDay applies trading.nonTradingDays to the request's date, which
+// must be set: it is rejected, moved to the previous trading day, or left as is.
+func checkMarketDay(req *AnalysisRequest) error {
+	mode := nonTradingDaysMode()
+	if mode == nonTradingDaysAllow {
+		return nil
+	}
+	day, err := time.Parse(validators.DateLayout, req.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date: %s", req.Date)
+	}
+	if isTradingDay(day) {
+		return nil
+	}
+	previous := previousTradingDay(day).Format(validators.DateLayout)
+	if mode == nonTradingDaysPrevious {
+		global.Logger.Warn("Analysis date is not a trading day, using the previous one",
+			"ticker", req.Ticker, "date", req.Date, "trading_day", previous)
+		req.Date = previous
+		return nil
+	}
+	return fmt.Errorf("date %s is not a trading day (weekend or market holiday); the previous trading day is %s", req.Date, previous)
+}
@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+const (
+	oauthProviderGoogle = "google"
+	oauthStateKeyPrefix = "oauth_state:"
+	oauthStateTTL       = 10 * time.Minute
+
+	googleAuthURL     = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+)
+
+var oauthHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// errOAuthUnverifiedAccount refuses to link a provider identity to a local
+// account whose email was never verified: whoever registered it may not own
+// the address, and linking would leave them a password into the owner's
+// account.
+var errOAuthUnverifiedAccount = errors.New("an account with this email already exists; sign in with its password and verify the email before using Google sign-in")
+
+// errOAuthEmailLinked refuses to link a provider identity to an account that
+// is already linked to another identity with the same email.
+var errOAuthEmailLinked = errors.New("this email is linked to a different account")
+
+// googleProfile is the subset of Google's OpenID Connect userinfo we use.
+type googleProfile struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// GoogleLogin redirects the browser to Google's consent screen. The state
+// parameter is stored in Redis and checked on the callback to prevent CSRF.
+// @Summary      Start Google sign-in
+// @Tags         auth
+// @Success      302
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/auth/oauth/google [get]
+func GoogleLogin(c *gin.Context) {
+	conf := config.AppConfig.OAuth.Google
+	if conf.ClientID == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "google login is not configured"})
+		return
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	state := hex.EncodeToString(b)
+	if err := global.RedisDB.Set(c.Request.Context(), oauthStateKeyPrefix+state, oauthProviderGoogle, oauthStateTTL).Err(); err != nil {
+		respondRedisError(c, err)
+		return
+	}
+
+	query := url.Values{
+		"client_id":     {conf.ClientID},
+		"redirect_uri":  {conf.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+	}
+	c.Redirect(http.StatusFound, googleAuthURL+"?"+query.Encode())
+}
+
+// GoogleCallback exchanges the authorization code, signs the Google account in
+// and returns the same token as the password login. A new account is created
+// unless one is already linked to the Google account or has the same
+// (Google-verified) email, in which case that account is linked and used. An
+// account whose own email is unverified is not linked (409).
+// @Summary      Complete Google sign-in
+// @Tags         auth
+// @Produce      json
+// @Param        code   query     string  true  "Authorization code"
+// @Param        state  query     string  true  "State from the login redirect"
+// @Success      200    {object}  map[string]string  "token"
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      409    {object}  map[string]string
+// @Failure      502    {object}  map[string]string
+// @Router       /api/v1/auth/oauth/google/callback [get]
+func GoogleCallback(c *gin.Context) {
+	ctx := c.Request.Context()
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "google login failed: " + errParam})
+		return
+	}
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing code or state"})
+		return
+	}
+
+	provider, err := global.RedisDB.GetDel(ctx, oauthStateKeyPrefix+state).Result()
+	if err == redis.Nil || (err == nil && provider != oauthProviderGoogle) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired login state"})
+		return
+	} else if err != nil {
+		respondRedisError(c, err)
+		return
+	}
+
+	profile, err := fetchGoogleProfile(ctx, code)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := upsertOAuthUser(oauthProviderGoogle, profile)
+	if errors.Is(err, errOAuthUnverifiedAccount) || errors.Is(err, errOAuthEmailLinked) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		respondDBError(c, err)
+		return
+	}
+	middlewares.InvalidateAuthUser(ctx, user.Username)
+	if !user.Active {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account is deactivated"})
+		return
+	}
+
+	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+// fetchGoogleProfile trades the authorization code for an access token and
+// reads the account's profile with it.
+func fetchGoogleProfile(ctx context.Context, code string) (*googleProfile, error) {
+	conf := config.AppConfig.OAuth.Google
+	form := url.Values{
+		"code":          {code},
+		"client_id":     {conf.ClientID},
+		"client_secret": {conf.ClientSecret},
+		"redirect_uri":  {conf.RedirectURL},
+		"grant_type":    {"authorization_code"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doOAuthRequest(req, &tokenResp); err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, errors.New("code exchange returned no access token")
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, googleUserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+tokenResp.AccessToken)
+
+	var profile googleProfile
+	if err := doOAuthRequest(req, &profile); err != nil {
+		return nil, fmt.Errorf("failed to fetch profile: %w", err)
+	}
+	if profile.Subject == "" {
+		return nil, errors.New("profile has no subject")
+	}
+	profile.Email = strings.ToLower(strings.TrimSpace(profile.Email))
+	return &profile, nil
+}
+
+func doOAuthRequest(req *http.Request, out interface{}) error {
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("provider returned status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// upsertOAuthUser finds the account linked to the provider identity, links an
+// existing account with the same email when both the provider and the account
+// have verified it, or creates a new one.
+func upsertOAuthUser(provider string, profile *googleProfile) (*models.User, error) {
+	var user models.User
+	err := global.DB.Where("oauth_provider = ? AND oauth_subject = ?", provider, profile.Subject).First(&user).Error
+	if err == nil {
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Only an address the provider has verified may take over an account
+	if profile.Email != "" && profile.EmailVerified {
+		err := global.DB.Where("email = ?", profile.Email).First(&user).Error
+		if err == nil {
+			if user.OAuthSubject != "" {
+				return nil, errOAuthEmailLinked
+			}
+			if !user.EmailVerified {
+				return nil, errOAuthUnverifiedAccount
+			}
+			if err := global.DB.Model(&user).Updates(map[string]interface{}{
+				"oauth_provider": provider,
+				"oauth_subject":  profile.Subject,
+			}).Error; err != nil {
+				return nil, err
+			}
+			return &user, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	username, err := availableUsername(profile)
+	if err != nil {
+		return nil, err
+	}
+	user = models.User{
+		Username:      username,
+		OAuthProvider: provider,
+		OAuthSubject:  profile.Subject,
+		Active:        true,
+	}
+	// An unverified address could belong to someone else; leave it off the account
+	if profile.EmailVerified {
+		user.Email = profile.Email
+		user.EmailVerified = true
+	}
+	if err := global.DB.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// availableUsername derives an unused username from the profile's email or
+// name, adding a numeric suffix when the base is taken.
+func availableUsername(profile *googleProfile) (string, error) {
+	base, _, _ := strings.Cut(profile.Email, "@")
+	if base == "" {
+		base = profile.Name
+	}
+	base = usernameInvalidChars.ReplaceAllString(strings.ToLower(base), "")
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 2; i < 100; i++ {
+		var count int64
+		if err := global.DB.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+	return "", errors.New("could not find a free username")
+}
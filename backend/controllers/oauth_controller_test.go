@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+)
+
+func TestUpsertOAuthUserLinksVerifiedAccount(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	local := models.User{Username: "alice", Password: "hash", Email: "alice@example.com", EmailVerified: true, Active: true}
+	if err := db.Create(&local).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := upsertOAuthUser(oauthProviderGoogle, &googleProfile{Subject: "g-1", Email: "alice@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != local.ID {
+		t.Fatalf("signed in as user %d, want the existing account %d", user.ID, local.ID)
+	}
+	var stored models.User
+	if err := db.First(&stored, local.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.OAuthProvider != oauthProviderGoogle || stored.OAuthSubject != "g-1" {
+		t.Fatalf("account linked to %q/%q, want google/g-1", stored.OAuthProvider, stored.OAuthSubject)
+	}
+}
+
+// Someone who registered the address first, without verifying it, must not end
+// up sharing the account with its real owner.
+func TestUpsertOAuthUserRefusesUnverifiedAccount(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	local := models.User{Username: "squatter", Password: "hash", Email: "alice@example.com", Active: true}
+	if err := db.Create(&local).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := upsertOAuthUser(oauthProviderGoogle, &googleProfile{Subject: "g-1", Email: "alice@example.com", EmailVerified: true})
+	if !errors.Is(err, errOAuthUnverifiedAccount) {
+		t.Fatalf("err = %v, want errOAuthUnverifiedAccount", err)
+	}
+	var stored models.User
+	if err := db.First(&stored, local.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.OAuthSubject != "" || stored.EmailVerified {
+		t.Fatalf("unverified account was changed: subject %q, email verified %v", stored.OAuthSubject, stored.EmailVerified)
+	}
+	var count int64
+	if err := db.Model(&models.User{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("%d users, want no new account for the taken email", count)
+	}
+}
+
+func TestUpsertOAuthUserRefusesAccountLinkedElsewhere(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	local := models.User{Username: "alice", Email: "alice@example.com", EmailVerified: true, Active: true,
+		OAuthProvider: oauthProviderGoogle, OAuthSubject: "g-1"}
+	if err := db.Create(&local).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := upsertOAuthUser(oauthProviderGoogle, &googleProfile{Subject: "g-2", Email: "alice@example.com", EmailVerified: true})
+	if !errors.Is(err, errOAuthEmailLinked) {
+		t.Fatalf("err = %v, want errOAuthEmailLinked", err)
+	}
+}
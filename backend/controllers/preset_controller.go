@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/db"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxPresetConfigBytes = 16 << 10
+	maxPresetConfigKeys  = 50
+)
+
+// presetRoundKeys are config keys the trading service reads as small positive
+// integers (debate and risk discussion rounds).
+var presetRoundKeys = []string{"max_debate_rounds", "max_risk_discuss_rounds"}
+
+type PresetInput struct {
+	Name   string                 `json:"name" binding:"required,max=100"`
+	Config map[string]interface{} `json:"config" binding:"required"`
+}
+
+// PresetResponse is an AnalysisPreset with its config decoded.
+type PresetResponse struct {
+	ID        uint                   `json:"id"`
+	Name      string                 `json:"name"`
+	Config    map[string]interface{} `json:"config"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+func newPresetResponse(p *models.AnalysisPreset) PresetResponse {
+	resp := PresetResponse{ID: p.ID, Name: p.Name, CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt}
+	_ = json.Unmarshal([]byte(p.Config), &resp.Config)
+	return resp
+}
+
+// validatePresetConfig checks that config is a reasonably small JSON object and
+// that the keys with a known meaning have values of the expected type.
+func validatePresetConfig(config map[string]interface{}) (string, error) {
+	if len(config) > maxPresetConfigKeys {
+		return "", fmt.Errorf("config cannot have more than %d keys", maxPresetConfigKeys)
+	}
+	for key := range config {
+		if strings.TrimSpace(key) == "" {
+			return "", errors.New("config keys cannot be empty")
+		}
+	}
+	for _, key := range presetRoundKeys {
+		v, ok := config[key]
+		if !ok {
+			continue
+		}
+		n, isNum := v.(float64)
+		if !isNum || n != math.Trunc(n) || n < 1 || n > 10 {
+			return "", fmt.Errorf("config.%s must be an integer between 1 and 10", key)
+		}
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxPresetConfigBytes {
+		return "", fmt.Errorf("config cannot exceed %d bytes", maxPresetConfigBytes)
+	}
+	return string(data), nil
+}
+
+// ListPresets returns the current user's analysis presets
+// @Summary      List analysis presets
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Router       /api/v1/trading/presets [get]
+func ListPresets(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var presets []models.AnalysisPreset
+	if err := global.DB.Where("user_id = ?", userID).Order("name").Find(&presets).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	resp := make([]PresetResponse, 0, len(presets))
+	for i := range presets {
+		resp = append(resp, newPresetResponse(&presets[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"presets": resp})
+}
+
+// CreatePreset saves a named analysis config
+// @Summary      Create an analysis preset
+// @Tags         trading
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      PresetInput  true  "Preset"
+// @Success      201   {object}  PresetResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Router       /api/v1/trading/presets [post]
+func CreatePreset(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var input PresetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	config, err := validatePresetConfig(input.Config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preset := models.AnalysisPreset{UserID: userID.(uint), Name: strings.TrimSpace(input.Name), Config: config}
+	if err := global.DB.Omit("User").Create(&preset).Error; err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "a preset with this name already exists"})
+			return
+		}
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newPresetResponse(&preset))
+}
+
+// UpdatePreset replaces a preset's name and config
+// @Summary      Update an analysis preset
+// @Tags         trading
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int          true  "Preset ID"
+// @Param        body  body      PresetInput  true  "Preset"
+// @Success      200   {object}  PresetResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Router       /api/v1/trading/presets/{id} [put]
+func UpdatePreset(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var input PresetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	config, err := validatePresetConfig(input.Config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var preset models.AnalysisPreset
+	if err := global.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&preset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "preset not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+	preset.Name = strings.TrimSpace(input.Name)
+	preset.Config = config
+	if err := global.DB.Omit("User").Save(&preset).Error; err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "a preset with this name already exists"})
+			return
+		}
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newPresetResponse(&preset))
+}
+
+// DeletePreset removes a preset. Analyses that used it keep their config.
+// @Summary      Delete an analysis preset
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  int  true  "Preset ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/trading/presets/{id} [delete]
+func DeletePreset(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	result := global.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.AnalysisPreset{})
+	if result.Error != nil {
+		respondDBError(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "preset not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// applyPreset merges the user's preset into req.Config. Keys given in the
+// request itself take precedence over the preset's.
+func applyPreset(userID uint, req *AnalysisRequest) *analysisError {
+	var preset models.AnalysisPreset
+	if err := global.DB.Where("id = ? AND user_id = ?", *req.PresetID, userID).First(&preset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &analysisError{http.StatusNotFound, "preset not found"}
+		}
+		return dbAnalysisError(err)
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(preset.Config), &config); err != nil {
+		return &analysisError{http.StatusInternalServerError, "stored preset config is invalid: " + err.Error()}
+	}
+	for k, v := range req.Config {
+		config[k] = v
+	}
+	req.Config = config
+	return nil
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/db"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UserProfile is the public view of a user; it never includes the password hash.
+type UserProfile struct {
+	ID            uint      `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"`
+	Active        bool      `json:"active"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// UpdateProfileInput holds the mutable profile fields. The username is fixed
+// because issued tokens refer to it.
+type UpdateProfileInput struct {
+	Email *string `json:"email" binding:"omitempty,email"`
+}
+
+func newUserProfile(user *models.User) UserProfile {
+	return UserProfile{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Role:          user.Role,
+		Active:        user.Active,
+		CreatedAt:     user.CreatedAt,
+	}
+}
+
+// GetProfile returns the authenticated user's profile
+// @Summary      Get the current user's profile
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  UserProfile
+// @Failure      401  {object}  map[string]string
+// @Router       /api/v1/auth/me [get]
+func GetProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := global.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserProfile(&user))
+}
+
+// UpdateProfile updates the authenticated user's profile. Changing the email
+// marks it unverified and sends a new verification link.
+// @Summary      Update the current user's profile
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      UpdateProfileInput  true  "Fields to update"
+// @Success      200   {object}  UserProfile
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Router       /api/v1/auth/me [put]
+func UpdateProfile(c *gin.Context) {
+	var input UpdateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := global.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	if input.Email == nil {
+		c.JSON(http.StatusOK, newUserProfile(&user))
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(*input.Email))
+	if email == user.Email {
+		c.JSON(http.StatusOK, newUserProfile(&user))
+		return
+	}
+
+	if email != "" {
+		var existing models.User
+		err := global.DB.Where("email = ? AND id <> ?", email, user.ID).First(&existing).Error
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "email already registered"})
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			respondDBError(c, err)
+			return
+		}
+	}
+
+	if err := global.DB.Model(&user).Updates(map[string]interface{}{
+		"email":          email,
+		"email_verified": false,
+	}).Error; err != nil {
+		if db.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "email already registered"})
+			return
+		}
+		respondDBError(c, err)
+		return
+	}
+	user.Email = email
+	user.EmailVerified = false
+	middlewares.InvalidateAuthUser(c.Request.Context(), user.Username)
+
+	if user.Email != "" {
+		sendVerificationEmail(c.Request.Context(), &user)
+	}
+
+	c.JSON(http.StatusOK, newUserProfile(&user))
+}
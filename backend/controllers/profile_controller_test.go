@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/JerryLinyx/FinGOAT/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserPasswordIsNeverSerialized(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	const password = "Secr3t!pass"
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{Username: "alice", Password: hash, Email: "alice@example.com", Role: "admin", Active: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/me", asUser(user.ID), GetProfile)
+	r.GET("/admin/users", ListUsers)
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bodies := map[string]string{
+		"json.Marshal(user)": string(data),
+		"GET /me":            testutil.Do(r, http.MethodGet, "/me", "").Body.String(),
+		"GET /admin/users":   testutil.Do(r, http.MethodGet, "/admin/users", "").Body.String(),
+	}
+	for source, body := range bodies {
+		if !strings.Contains(body, `"alice"`) {
+			t.Errorf("%s = %s, want the user", source, body)
+		}
+		if strings.Contains(body, hash) || strings.Contains(body, password) {
+			t.Errorf("%s exposes the password: %s", source, body)
+		}
+		if strings.Contains(body, `"password"`) {
+			t.Errorf("%s has a password field: %s", source, body)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ScheduleInput creates or replaces a scheduled analysis. RunAt is HH:MM in the
+// scheduler's time zone; Active defaults to true.
+type ScheduleInput struct {
+	Ticker      string `json:"ticker" binding:"required,max=10"`
+	RunAt       string `json:"run_at" binding:"required"`
+	LLMProvider string `json:"llm_provider,omitempty"`
+	LLMModel    string `json:"llm_model,omitempty"`
+	Active      *bool  `json:"active,omitempty"`
+}
+
+// toSchedule validates the input and copies it onto s.
+func (in *ScheduleInput) toSchedule(s *models.ScheduledAnalysis) error {
+	runAt, err := time.Parse("15:04", in.RunAt)
+	if err != nil {
+		return errors.New("run_at must be a time in HH:MM format")
+	}
+	provider := strings.ToLower(in.LLMProvider)
+	if err := checkLLMChoice(provider, in.LLMModel); err != nil {
+		return err
+	}
+
+	s.Ticker = strings.ToUpper(strings.TrimSpace(in.Ticker))
+	s.RunAt = runAt.Format("15:04")
+	s.LLMProvider = provider
+	s.LLMModel = in.LLMModel
+	s.Active = in.Active == nil || *in.Active
+	return nil
+}
+
+// ListSchedules returns the current user's scheduled analyses
+// @Summary      List scheduled analyses
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Router       /api/v1/trading/schedules [get]
+func ListSchedules(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var schedules []models.ScheduledAnalysis
+	if err := global.DB.Where("user_id = ?", userID).Order("id").Find(&schedules).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules})
+}
+
+// CreateSchedule schedules a daily analysis of a ticker
+// @Summary      Create a scheduled analysis
+// @Tags         trading
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      ScheduleInput  true  "Schedule"
+// @Success      201   {object}  models.ScheduledAnalysis
+// @Failure      400   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Router       /api/v1/trading/schedules [post]
+func CreateSchedule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var input ScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schedule := models.ScheduledAnalysis{UserID: userID.(uint)}
+	if err := input.toSchedule(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Every schedule runs a paid analysis each trading day
+	if limit := config.AppConfig.Schedule.MaxPerUser; limit > 0 {
+		var count int64
+		if err := global.DB.Model(&models.ScheduledAnalysis{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			respondDBError(c, err)
+			return
+		}
+		if count >= int64(limit) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("schedule limit of %d reached; delete one first", limit)})
+			return
+		}
+	}
+	if err := global.DB.Omit("User").Create(&schedule).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// UpdateSchedule replaces a scheduled analysis
+// @Summary      Update a scheduled analysis
+// @Tags         trading
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int            true  "Schedule ID"
+// @Param        body  body      ScheduleInput  true  "Schedule"
+// @Success      200   {object}  models.ScheduledAnalysis
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Router       /api/v1/trading/schedules/{id} [put]
+func UpdateSchedule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var input ScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var schedule models.ScheduledAnalysis
+	if err := global.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+	if err := input.toSchedule(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := global.DB.Omit("User").Save(&schedule).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule removes a scheduled analysis. Tasks it already started are kept.
+// @Summary      Delete a scheduled analysis
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  int  true  "Schedule ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/trading/schedules/{id} [delete]
+func DeleteSchedule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	result := global.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.ScheduledAnalysis{})
+	if result.Error != nil {
+		respondDBError(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// scheduleLocation returns the configured scheduler time zone, New York (the
+// US market calendar) by default.
+func scheduleLocation() (*time.Location, error) {
+	tz := config.AppConfig.Schedule.Timezone
+	if tz == "" {
+		tz = "America/New_York"
+	}
+	return time.LoadLocation(tz)
+}
+
+// RunDueSchedules submits an analysis for every active schedule whose run time
+// has passed today and that has not yet run today. A failed submission leaves
+// the schedule due, so it is retried on the next call. Runs count against the
+// owner's daily quota; once it is used up, the day's run is skipped.
+func RunDueSchedules(ctx context.Context) error {
+	loc, err := scheduleLocation()
+	if err != nil {
+		return err
+	}
+	now := time.Now().In(loc)
+	if !isTradingDay(now) {
+		return nil
+	}
+	today := now.Format("2006-01-02")
+
+	var due []models.ScheduledAnalysis
+	if err := global.DB.WithContext(ctx).
+		Where("active AND run_at <= ? AND (last_run_date IS NULL OR last_run_date <> ?)", now.Format("15:04"), today).
+		Preload("User").
+		Order("run_at, id").
+		Find(&due).Error; err != nil {
+		return err
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		schedule := &due[i]
+		req := AnalysisRequest{
+			Ticker:      schedule.Ticker,
+			Date:        today,
+			LLMProvider: schedule.LLMProvider,
+			LLMModel:    schedule.LLMModel,
+			ScheduleID:  &schedule.ID,
+		}
+
+		quota, err := analysisQuota(schedule.UserID, schedule.User.Role)
+		if err != nil {
+			global.Logger.Error("Failed to check quota of scheduled analysis", "schedule_id", schedule.ID, "error", err)
+			continue
+		}
+		if quota.Limit > 0 && quota.Remaining < 1 {
+			global.Logger.Info("Scheduled analysis skipped: daily quota exceeded",
+				"schedule_id", schedule.ID, "ticker", schedule.Ticker, "user_id", schedule.UserID)
+			updates := map[string]interface{}{"last_run_date": today, "last_error": "daily analysis quota exceeded; run skipped"}
+			if err := global.DB.WithContext(ctx).Model(schedule).Updates(updates).Error; err != nil {
+				global.Logger.Error("Failed to record skipped run", "schedule_id", schedule.ID, "error", err)
+			}
+			continue
+		}
+
+		updates := map[string]interface{}{"last_run_date": today, "last_error": ""}
+		task, _, aerr := submitAnalysis(ctx, schedule.UserID, req)
+		if aerr != nil {
+			global.Logger.Warn("Scheduled analysis failed; will retry",
+				"schedule_id", schedule.ID, "ticker", schedule.Ticker, "error", aerr.message)
+			updates = map[string]interface{}{"last_error": aerr.message}
+		} else {
+			global.Logger.Info("Scheduled analysis submitted",
+				"schedule_id", schedule.ID, "ticker", schedule.Ticker, "task_id", task.TaskID)
+		}
+		if err := global.DB.WithContext(ctx).Model(schedule).Updates(updates).Error; err != nil {
+			global.Logger.Error("Failed to record scheduled run", "schedule_id", schedule.ID, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateScheduleEnforcesLimit(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.Schedule.MaxPerUser = 2
+	testutil.DB(t)
+
+	r := gin.New()
+	r.POST("/schedules", asUser(1), CreateSchedule)
+	const body = `{"ticker":"AAPL","run_at":"09:45"}`
+	for i := range 2 {
+		if w := testutil.Do(r, http.MethodPost, "/schedules", body); w.Code != http.StatusCreated {
+			t.Fatalf("schedule %d: status %d, want 201: %s", i+1, w.Code, w.Body)
+		}
+	}
+	if w := testutil.Do(r, http.MethodPost, "/schedules", body); w.Code != http.StatusConflict {
+		t.Fatalf("schedule over the limit: status %d, want 409: %s", w.Code, w.Body)
+	}
+}
+
+func TestRunDueSchedulesRespectsDailyQuota(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.Schedule.Timezone = "UTC"
+	conf.Trading.DailyQuota = 1
+	if !isTradingDay(time.Now().UTC()) {
+		t.Skip("schedules only run on trading days")
+	}
+	db := testutil.DB(t)
+	if err := db.Create(&models.User{Username: "alice", Password: "x", Active: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := db.Create(&models.ScheduledAnalysis{UserID: 1, Ticker: "AAPL", RunAt: "00:00", Active: true}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	var submitted atomic.Int64
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		submitted.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"task_id":"t1","status":"pending"}`))
+	})
+
+	if err := RunDueSchedules(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := submitted.Load(); n != 1 {
+		t.Fatalf("%d submissions, want 1 within the quota", n)
+	}
+	var skipped models.ScheduledAnalysis
+	if err := db.Order("id DESC").First(&skipped).Error; err != nil {
+		t.Fatal(err)
+	}
+	if skipped.LastError == "" || skipped.LastRunDate == "" {
+		t.Fatalf("schedule over the quota: last_error %q, last_run_date %q, want the skipped run recorded",
+			skipped.LastError, skipped.LastRunDate)
+	}
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagsInput struct {
+	Tags []string `json:"tags" binding:"required,min=1,max=20,dive,required,max=50"`
+}
+
+// TagCount is a tag with the number of (non-deleted) articles carrying it.
+type TagCount struct {
+	Name     string `json:"name"`
+	Articles int64  `gorm:"column:article_count" json:"articles"`
+}
+
+// normalizeTagName makes tag matching case-insensitive.
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ListTags returns every tag with its article count, most used first
+// @Summary      List tags
+// @Tags         articles
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   TagCount
+// @Failure      500  {object}  map[string]string
+// @Router       /api/v1/tags [get]
+func ListTags(c *gin.Context) {
+	counts := []TagCount{}
+	if err := global.DB.Model(&models.Tag{}).
+		Select("tags.name, COUNT(articles.id) AS article_count").
+		Joins("LEFT JOIN article_tags ON article_tags.tag_id = tags.id").
+		Joins("LEFT JOIN articles ON articles.id = article_tags.article_id AND articles.deleted_at IS NULL").
+		Group("tags.name").
+		Order("article_count DESC, tags.name").
+		Scan(&counts).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, counts)
+}
+
+// AddArticleTags attaches tags to an article, creating tags that don't exist yet
+// @Summary      Tag an article
+// @Tags         articles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int        true  "Article ID"
+// @Param        body  body      TagsInput  true  "Tag names"
+// @Success      200   {object}  models.Article
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Router       /api/v1/articles/{id}/tags [post]
+func AddArticleTags(c *gin.Context) {
+	var input TagsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	names := make([]string, 0, len(input.Tags))
+	for _, name := range input.Tags {
+		if name = normalizeTagName(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no tags given"})
+		return
+	}
+
+	var article models.Article
+	if err := global.DB.First(&article, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "article not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		newTags := make([]models.Tag, len(names))
+		for i, name := range names {
+			newTags[i] = models.Tag{Name: name}
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoNothing: true,
+		}).Create(&newTags).Error; err != nil {
+			return err
+		}
+
+		var tags []models.Tag
+		if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+			return err
+		}
+		return tx.Model(&article).Association("Tags").Append(&tags)
+	})
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	respondWithTaggedArticle(c, article.ID)
+}
+
+// RemoveArticleTag detaches a tag from an article. The tag itself is kept.
+// @Summary      Untag an article
+// @Tags         articles
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int     true  "Article ID"
+// @Param        tag  path      string  true  "Tag name"
+// @Success      200  {object}  models.Article
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/articles/{id}/tags/{tag} [delete]
+func RemoveArticleTag(c *gin.Context) {
+	var article models.Article
+	if err := global.DB.First(&article, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "article not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	var tag models.Tag
+	if err := global.DB.Where("name = ?", normalizeTagName(c.Param("tag"))).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	if err := global.DB.Model(&article).Association("Tags").Delete(&tag); err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	respondWithTaggedArticle(c, article.ID)
+}
+
+// respondWithTaggedArticle invalidates the article cache and returns the
+// article with its current tags.
+func respondWithTaggedArticle(c *gin.Context, id uint) {
+	invalidateArticlesCache(c.Request.Context())
+
+	var article models.Article
+	if err := global.DB.Preload("Tags").First(&article, id).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/cache"
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const tickerCacheKeyPrefix = "ticker:"
+
+var (
+	// errTickerNotFound means the provider has no company for the symbol.
+	errTickerNotFound = errors.New("unknown ticker")
+	// errMarketDataDisabled means no market-data API key is configured.
+	errMarketDataDisabled = errors.New("ticker lookup is not configured")
+)
+
+// tickerPattern matches the symbols accepted for lookup, e.g. BRK.B or RDS-A.
+var tickerPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9.\-]{0,9}$`)
+
+// TickerInfo is basic metadata about a listed company.
+type TickerInfo struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Exchange string `json:"exchange"`
+	Currency string `json:"currency"`
+}
+
+// tickerCacheEntry is what is cached per symbol. Info is nil for symbols the
+// provider does not know, so repeated lookups of a typo don't reach it.
+type tickerCacheEntry struct {
+	Info *TickerInfo `json:"info"`
+}
+
+// marketDataHTTPClient is built on first use, after config is loaded.
+var marketDataHTTPClient = sync.OnceValue(func() *http.Client {
+	timeout := config.AppConfig.MarketData.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &http.Client{Timeout: timeout}
+})
+
+// GetTickerInfo returns the company behind a ticker symbol
+// @Summary      Look up a ticker
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        symbol  path      string  true  "Ticker symbol, e.g. NVDA"
+// @Success      200     {object}  TickerInfo
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      503     {object}  map[string]string
+// @Router       /api/v1/trading/ticker/{symbol} [get]
+func GetTickerInfo(c *gin.Context) {
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+	if !tickerPattern.MatchString(symbol) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticker symbol"})
+		return
+	}
+
+	info, err := lookupTicker(c.Request.Context(), symbol)
+	switch {
+	case errors.Is(err, errTickerNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown ticker: " + symbol})
+	case err != nil:
+		if !errors.Is(err, errMarketDataDisabled) {
+			global.Logger.Warn("Ticker lookup failed", "symbol", symbol, "error", err)
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ticker lookup is unavailable"})
+	default:
+		c.JSON(http.StatusOK, info)
+	}
+}
+
+// ListTickerAnalyses lists the current user's analyses of one ticker, newest
+// first. A ticker the user never analysed gives an empty list, not a 404.
+// @Summary      List the current user's analyses of a ticker
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        symbol     path      string  true   "Ticker symbol, e.g. NVDA"
+// @Param        page       query     int     false  "Page (default 1)"
+// @Param        page_size  query     int     false  "Page size (default 20, max 100)"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]string
+// @Router       /api/v1/trading/ticker/{symbol}/analyses [get]
+func ListTickerAnalyses(c *gin.Context) {
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+	if !tickerPattern.MatchString(symbol) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticker symbol"})
+		return
+	}
+
+	offset, limit := pagination.Parse(c)
+	// Tickers are stored as the user typed them
+	query := userAnalysesQuery(global.DB, c.GetUint("user_id")).
+		Where("UPPER(trading_analysis_tasks.ticker) = ?", symbol)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	tasks := []models.TradingAnalysisTask{}
+	if err := query.Session(&gorm.Session{}).
+		Preload("Decision").
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&tasks).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ticker":     symbol,
+		"tasks":      tasks,
+		"total":      total,
+		"pagination": pagination.NewMeta(offset, limit, total),
+	})
+}
+
+// checkTickerKnown rejects tickers the market-data provider does not know.
+// When lookups are disabled or the provider cannot be reached, every ticker
+// passes and the trading service has the final say.
+func checkTickerKnown(ctx context.Context, ticker string) error {
+	if config.AppConfig.MarketData.APIKey == "" {
+		return nil
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(ticker))
+	if !tickerPattern.MatchString(symbol) {
+		return fmt.Errorf("invalid ticker symbol: %s", ticker)
+	}
+	_, err := lookupTicker(ctx, symbol)
+	switch {
+	case errors.Is(err, errTickerNotFound):
+		return fmt.Errorf("unknown ticker: %s", symbol)
+	case err != nil:
+		global.Logger.Warn("Ticker lookup failed, accepting ticker unchecked", "symbol", symbol, "error", err)
+	}
+	return nil
+}
+
+// lookupTicker returns the metadata of an upper-case symbol, from the cache
+// when possible. It returns errTickerNotFound for unknown symbols.
+func lookupTicker(ctx context.Context, symbol string) (*TickerInfo, error) {
+	conf := config.AppConfig.MarketData
+	if conf.APIKey == "" {
+		return nil, errMarketDataDisabled
+	}
+
+	key := tickerCacheKeyPrefix + symbol
+	var entry tickerCacheEntry
+	if hit, _ := cache.GetJSON(ctx, key, &entry); hit {
+		if entry.Info == nil {
+			return nil, errTickerNotFound
+		}
+		return entry.Info, nil
+	}
+
+	info, err := fetchTickerInfo(ctx, symbol)
+	if err != nil && !errors.Is(err, errTickerNotFound) {
+		return nil, err
+	}
+	ttl := conf.CacheTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	if info == nil {
+		// A listing may appear later, so misses are remembered for less long
+		ttl /= 10
+	}
+	_ = cache.SetJSON(ctx, key, tickerCacheEntry{Info: info}, ttl)
+	return info, err
+}
+
+// alphaVantageOverview is the subset of Alpha Vantage's OVERVIEW response
+// used here. Unknown symbols get an empty object, and throttled requests a
+// Note or Information message instead of data.
+type alphaVantageOverview struct {
+	Symbol      string `json:"Symbol"`
+	Name        string `json:"Name"`
+	Exchange    string `json:"Exchange"`
+	Currency    string `json:"Currency"`
+	Note        string `json:"Note"`
+	Information string `json:"Information"`
+	Error       string `json:"Error Message"`
+}
+
+func fetchTickerInfo(ctx context.Context, symbol string) (*TickerInfo, error) {
+	conf := config.AppConfig.MarketData
+	providerURL := conf.ProviderURL
+	if providerURL == "" {
+		providerURL = "https://www.alphavantage.co/query"
+	}
+	endpoint, err := url.Parse(providerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider url: %w", err)
+	}
+	query := endpoint.Query()
+	query.Set("function", "OVERVIEW")
+	query.Set("symbol", symbol)
+	query.Set("apikey", conf.APIKey)
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := marketDataHTTPClient().Do(req)
+	if err != nil {
+		// The URL carries the API key; report the failure without it
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("provider returned status %d", resp.StatusCode)
+	}
+
+	var overview alphaVantageOverview
+	if err := json.Unmarshal(body, &overview); err != nil {
+		return nil, fmt.Errorf("failed to parse provider response: %w", err)
+	}
+	if msg := firstNonEmpty(overview.Note, overview.Information); msg != "" {
+		return nil, fmt.Errorf("provider refused the request: %s", msg)
+	}
+	if overview.Symbol == "" || overview.Error != "" {
+		return nil, errTickerNotFound
+	}
+	return &TickerInfo{
+		Symbol:   overview.Symbol,
+		Name:     overview.Name,
+		Exchange: overview.Exchange,
+		Currency: overview.Currency,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/metrics"
+)
+
+// errTradingCircuitOpen is returned by callTradingService without calling the
+// service while the circuit breaker is open.
+var errTradingCircuitOpen = errors.New("trading service is unavailable: circuit breaker open")
+
+// callOutcome is how a call to the Python service counts toward the breaker.
+type callOutcome int
+
+const (
+	callSucceeded callOutcome = iota
+	callFailed                // no response, or a 5xx one
+	callAbandoned             // never reached the service, or the caller gave up
+)
+
+// circuitBreaker stops calls to the Python service after
+// trading.breakerThreshold consecutive failures, so requests fail fast instead
+// of each waiting out the client timeout. After trading.breakerCooldown a
+// single probe call is let through: success closes the breaker, failure opens
+// it for another cooldown.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	state    int // one of the metrics.Circuit* values
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+var tradingBreaker circuitBreaker
+
+func breakerThreshold() int {
+	if n := config.AppConfig.Trading.BreakerThreshold; n > 0 {
+		return n
+	}
+	return 5
+}
+
+func breakerCooldown() time.Duration {
+	if d := config.AppConfig.Trading.BreakerCooldown; d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// allow reports whether a call may go ahead. Every call allowed must be
+// followed by a record of its outcome.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case metrics.CircuitOpen:
+		if time.Since(b.openedAt) < breakerCooldown() {
+			return errTradingCircuitOpen
+		}
+		b.setState(metrics.CircuitHalfOpen)
+	case metrics.CircuitHalfOpen:
+		if b.probing {
+			return errTradingCircuitOpen
+		}
+	default:
+		return nil
+	}
+	b.probing = true
+	return nil
+}
+
+// record updates the breaker with the outcome of a call allowed by allow.
+func (b *circuitBreaker) record(outcome callOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	probe := b.state == metrics.CircuitHalfOpen
+	if probe {
+		b.probing = false
+	}
+	switch outcome {
+	case callSucceeded:
+		b.failures = 0
+		b.setState(metrics.CircuitClosed)
+	case callFailed:
+		b.failures++
+		if probe || b.failures >= breakerThreshold() {
+			b.openedAt = time.Now()
+			b.setState(metrics.CircuitOpen)
+		}
+	}
+}
+
+// stateName returns the breaker state as reported by the health check.
+func (b *circuitBreaker) stateName() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case metrics.CircuitOpen:
+		return "open"
+	case metrics.CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+func (b *circuitBreaker) setState(state int) {
+	b.state = state
+	metrics.TradingCircuitBreakerState.Set(float64(state))
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/metrics"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTradingCircuitBreaker(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.Trading.BreakerThreshold = 3
+	conf.Trading.BreakerCooldown = 50 * time.Millisecond
+
+	var calls atomic.Int64
+	var healthy atomic.Bool
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			http.Error(w, `{"detail":"boom"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	call := func() error {
+		_, err := callTradingService(context.Background(), http.MethodGet, "/health", nil, nil)
+		return err
+	}
+	wantState := func(state int) {
+		t.Helper()
+		if got := promtest.ToFloat64(metrics.TradingCircuitBreakerState); got != float64(state) {
+			t.Fatalf("breaker gauge = %v, want %d", got, state)
+		}
+	}
+
+	for i := range 3 {
+		var serviceErr *tradingServiceError
+		if err := call(); !errors.As(err, &serviceErr) {
+			t.Fatalf("call %d: err = %v, want the service's 500", i, err)
+		}
+	}
+	wantState(metrics.CircuitOpen)
+	if err := call(); !errors.Is(err, errTradingCircuitOpen) {
+		t.Fatalf("err = %v while open, want errTradingCircuitOpen", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("service called %d times, want 3: the open breaker must not call it", n)
+	}
+
+	// A failed probe opens the breaker for another cooldown
+	time.Sleep(60 * time.Millisecond)
+	if err := call(); errors.Is(err, errTradingCircuitOpen) {
+		t.Fatal("probe call after the cooldown was not let through")
+	}
+	wantState(metrics.CircuitOpen)
+	if err := call(); !errors.Is(err, errTradingCircuitOpen) {
+		t.Fatalf("err = %v after a failed probe, want errTradingCircuitOpen", err)
+	}
+
+	// A successful probe closes it
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	if err := call(); err != nil {
+		t.Fatalf("probe call: %v", err)
+	}
+	wantState(metrics.CircuitClosed)
+	if err := call(); err != nil {
+		t.Fatalf("call after closing: %v", err)
+	}
+}
+
+func TestTradingCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.Trading.BreakerThreshold = 2
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"detail":"not found"}`, http.StatusNotFound)
+	})
+
+	for range 5 {
+		_, err := callTradingService(context.Background(), http.MethodGet, "/api/v1/analysis/x", nil, nil)
+		if errors.Is(err, errTradingCircuitOpen) {
+			t.Fatal("4xx responses opened the breaker")
+		}
+	}
+	if got := promtest.ToFloat64(metrics.TradingCircuitBreakerState); got != metrics.CircuitClosed {
+		t.Fatalf("breaker gauge = %v, want closed", got)
+	}
+}
+
+func TestCheckServiceHealthReportsBreakerState(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.Trading.BreakerThreshold = 1
+	conf.Trading.BreakerCooldown = time.Minute
+	var down atomic.Bool
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, `{"detail":"down"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+
+	r := gin.New()
+	r.GET("/health", CheckServiceHealth)
+	check := func(wantCode int, wantState string) {
+		t.Helper()
+		w := testutil.Do(r, http.MethodGet, "/health", "")
+		var resp struct {
+			CircuitBreaker string `json:"circuit_breaker"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != wantCode || resp.CircuitBreaker != wantState {
+			t.Fatalf("status %d, circuit_breaker %q, want %d and %q", w.Code, resp.CircuitBreaker, wantCode, wantState)
+		}
+	}
+
+	check(http.StatusOK, "closed")
+	down.Store(true)
+	check(http.StatusServiceUnavailable, "open")
+	// Reported without calling the service again
+	check(http.StatusServiceUnavailable, "open")
+}
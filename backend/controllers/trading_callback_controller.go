@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TradingServiceCallback lets the Python service push a task's state instead
+// of waiting to be polled. The payload is the same as the service's analysis
+// response. Updates for tasks that already finished are acknowledged without
+// changes, so the service may safely retry. Callers are authenticated by
+// InternalAuth.
+// @Summary      Receive an analysis update from the trading service
+// @Tags         internal
+// @Accept       json
+// @Produce      json
+// @Param        X-Internal-Secret     header    string                 false  "Shared secret, unless the request is signed"
+// @Param        X-Internal-Signature  header    string                 false  "sha256=HMAC of timestamp.body"
+// @Param        X-Internal-Timestamp  header    string                 false  "Unix seconds, with X-Internal-Signature"
+// @Param        body                  body      PythonServiceResponse  true   "Task state"
+// @Success      200                   {object}  map[string]string
+// @Failure      401                   {object}  map[string]string
+// @Failure      404                   {object}  map[string]string
+// @Failure      500                   {object}  map[string]string
+// @Router       /internal/trading/callback [post]
+func TradingServiceCallback(c *gin.Context) {
+	var payload PythonServiceResponse
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if payload.TaskID == "" || payload.Status == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_id and status are required"})
+		return
+	}
+
+	var task models.TradingAnalysisTask
+	if err := global.DB.Where("task_id = ?", payload.TaskID).Preload("Decision").First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+	if !isActiveTaskStatus(task.Status) {
+		c.JSON(http.StatusOK, gin.H{"status": task.Status, "message": "task already finished"})
+		return
+	}
+
+	applyServiceResponse(&task, &payload)
+	c.JSON(http.StatusOK, gin.H{"status": task.Status})
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTaskLookupErrors(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+
+	r := gin.New()
+	r.GET("/analysis/:task_id", asUser(1), GetAnalysisResult)
+	r.POST("/callback", TradingServiceCallback)
+	get := func() int { return testutil.Do(r, http.MethodGet, "/analysis/missing", "").Code }
+	callback := func() int {
+		return testutil.Do(r, http.MethodPost, "/callback", `{"task_id":"missing","status":"completed"}`).Code
+	}
+
+	if code := get(); code != http.StatusNotFound {
+		t.Fatalf("GetAnalysisResult for an unknown task: status %d, want 404", code)
+	}
+	if code := callback(); code != http.StatusNotFound {
+		t.Fatalf("TradingServiceCallback for an unknown task: status %d, want 404", code)
+	}
+
+	// Any other failure is the server's, not a missing task
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+	if code := get(); code != http.StatusInternalServerError {
+		t.Fatalf("GetAnalysisResult with the database down: status %d, want 500", code)
+	}
+	if code := callback(); code != http.StatusInternalServerError {
+		t.Fatalf("TradingServiceCallback with the database down: status %d, want 500", code)
+	}
+}
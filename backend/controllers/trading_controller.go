@@ -2,27 +2,238 @@ package controllers
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/db"
 	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/metrics"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
 	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/pagination"
+	"github.com/JerryLinyx/FinGOAT/validators"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/semaphore"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-const TRADING_SERVICE_URL = "http://localhost:8001"
+// tradingHTTPClient is shared by every call to the Python service so that
+// connections are pooled. It is built on first use, after config is loaded.
+var tradingHTTPClient = sync.OnceValue(func() *http.Client {
+	cfg := config.AppConfig.Trading
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = 100
+	}
+	maxIdlePerHost := cfg.MaxIdleConnsPerHost
+	if maxIdlePerHost <= 0 {
+		maxIdlePerHost = 20
+	}
+	idleTimeout := cfg.IdleConnTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = 90 * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxIdle
+	transport.MaxIdleConnsPerHost = maxIdlePerHost
+	transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	transport.IdleConnTimeout = idleTimeout
+	return &http.Client{Timeout: timeout, Transport: transport}
+})
+
+// tradingCallSlots bounds the calls to the Python service in flight at once,
+// so batches, the reconciler and streaming clients together cannot overload
+// it. Built on first use, after config is loaded.
+var tradingCallSlots = sync.OnceValue(func() *semaphore.Weighted {
+	limit := config.AppConfig.Trading.MaxConcurrentCalls
+	if limit <= 0 {
+		limit = 16
+	}
+	metrics.TradingCallsLimit.Set(float64(limit))
+	return semaphore.NewWeighted(int64(limit))
+})
+
+// acquireTradingSlot waits until a call to the Python service may start or
+// ctx is done. The returned function frees the slot.
+func acquireTradingSlot(ctx context.Context) (func(), error) {
+	slots := tradingCallSlots()
+	if !slots.TryAcquire(1) {
+		metrics.TradingCallsWaiting.Inc()
+		err := slots.Acquire(ctx, 1)
+		metrics.TradingCallsWaiting.Dec()
+		if err != nil {
+			return nil, fmt.Errorf("waiting for a free trading service slot: %w", err)
+		}
+	}
+	metrics.TradingCallsInFlight.Inc()
+	return func() {
+		metrics.TradingCallsInFlight.Dec()
+		slots.Release(1)
+	}, nil
+}
+
+// tradingServiceError is a non-2xx response from the Python service.
+type tradingServiceError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *tradingServiceError) Error() string {
+	return e.Message
+}
+
+// maxTradingResponseBytes bounds how much of a response is read.
+const maxTradingResponseBytes = 32 << 20
+
+// callTradingService sends body (if non-nil) as JSON to the Python service and
+// decodes the response into out (if non-nil). It returns the response status,
+// or 0 when no response was received. A non-2xx status is returned as a
+// *tradingServiceError carrying the service's error message; out is still
+// filled from the body when it parses. The request ID travels with ctx. When
+// trading.maxConcurrentCalls calls are already in flight it waits for one
+// to finish, for as long as ctx allows. While the circuit breaker is open it
+// returns errTradingCircuitOpen without calling the service.
+func callTradingService(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	var reqData []byte
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reqData = data
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(config.AppConfig.Trading.ServiceURL, "/")+path, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if requestID := middlewares.RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set(middlewares.RequestIDHeader, requestID)
+	}
 
-var tradingHTTPClient = &http.Client{Timeout: 15 * time.Second}
+	var (
+		status   int
+		respBody []byte
+	)
+	if tradingDebugEnabled(ctx) {
+		start := time.Now()
+		defer func() {
+			logTradingExchange(ctx, method, path, reqData, status, respBody, time.Since(start), err)
+		}()
+	}
+
+	if err = tradingBreaker.allow(); err != nil {
+		return 0, err
+	}
+	release, err := acquireTradingSlot(ctx)
+	if err != nil {
+		tradingBreaker.record(callAbandoned)
+		return 0, err
+	}
+	defer release()
+	resp, err := tradingHTTPClient().Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			tradingBreaker.record(callAbandoned)
+		} else {
+			tradingBreaker.record(callFailed)
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	status = resp.StatusCode
+	if status >= 500 {
+		tradingBreaker.record(callFailed)
+	} else {
+		tradingBreaker.record(callSucceeded)
+	}
+	respBody, err = io.ReadAll(io.LimitReader(resp.Body, maxTradingResponseBytes))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if out != nil {
+			_ = json.Unmarshal(respBody, out)
+		}
+		err = &tradingServiceError{resp.StatusCode, extractTradingServiceError(respBody, resp.StatusCode)}
+		return resp.StatusCode, err
+	}
+	if out != nil {
+		if err = json.Unmarshal(respBody, out); err != nil {
+			err = fmt.Errorf("failed to parse trading service response: %w", err)
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
 
 // Request/Response structures for Python service
 type AnalysisRequest struct {
-	Ticker    string                 `json:"ticker" binding:"required"`
-	Date      string                 `json:"date" binding:"required"`
+	Ticker      string                 `json:"ticker" binding:"required"`
+	Date        string                 `json:"date,omitempty" binding:"omitempty,isodate"` // defaults to today in trading.timezone
+	LLMProvider string                 `json:"llm_provider,omitempty"`
+	LLMModel    string                 `json:"llm_model,omitempty"`
+	LLMBaseURL  string                 `json:"llm_base_url,omitempty" binding:"omitempty,url"`
+	LLMConfig   map[string]interface{} `json:"llm_config,omitempty"`
+	Config      map[string]interface{} `json:"config,omitempty"`
+	// CallbackURL overrides the user's webhook URL for this analysis
+	CallbackURL string `json:"callback_url,omitempty" binding:"omitempty,http_url"`
+	// PresetID applies one of the user's saved configs; keys in Config override it
+	PresetID *uint `json:"preset_id,omitempty"`
+	// DryRun returns a canned result instead of calling the trading service;
+	// only accepted when trading.dryRunEnabled is set
+	DryRun bool `json:"dry_run,omitempty"`
+	// ScheduleID is set by the scheduler and never bound from a request
+	ScheduleID *uint `json:"-"`
+	// RetryOf is set when re-running a failed task and never bound from a request
+	RetryOf string `json:"-"`
+}
+
+// pythonAnalysisRequest is the payload sent to the Python service's analyze endpoint
+type pythonAnalysisRequest struct {
+	Ticker    string                 `json:"ticker"`
+	Date      string                 `json:"date"`
 	LLMConfig map[string]interface{} `json:"llm_config,omitempty"`
+	Config    map[string]interface{} `json:"config,omitempty"`
+}
+
+// knownLLMProviders mirrors the providers supported by TradingAgents' llm_provider.py
+var knownLLMProviders = map[string]bool{
+	"openai":            true,
+	"openai-compatible": true,
+	"vllm":              true,
+	"openrouter":        true,
+	"deepseek":          true,
+	"aliyun":            true,
+	"anthropic":         true,
+	"google":            true,
+	"ollama":            true,
 }
 
 type PythonServiceResponse struct {
@@ -40,6 +251,15 @@ type PythonServiceResponse struct {
 	ProcessingTimeSeconds float64                `json:"processing_time_seconds"`
 }
 
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 func extractTradingServiceError(body []byte, statusCode int) string {
 	var errResp map[string]interface{}
 	if err := json.Unmarshal(body, &errResp); err == nil {
@@ -74,95 +294,440 @@ func extractTradingServiceError(body []byte, statusCode int) string {
 	return fmt.Sprintf("trading service returned status %d", statusCode)
 }
 
-// RequestAnalysis submits a new trading analysis request
+// maxBatchAnalysisItems caps the number of tickers accepted by RequestBatchAnalysis.
+const maxBatchAnalysisItems = 20
+
+type BatchAnalysisRequest struct {
+	Items []AnalysisRequest `json:"items" binding:"required,min=1,max=20,dive"`
+}
+
+// BatchAnalysisResult reports the outcome of one item in a batch submission.
+type BatchAnalysisResult struct {
+	Index    int                         `json:"index"`
+	Ticker   string                      `json:"ticker"`
+	Date     string                      `json:"date"`
+	Task     *models.TradingAnalysisTask `json:"task,omitempty"`
+	Existing bool                        `json:"existing,omitempty"` // the service returned a task that was already recorded
+	Error    string                      `json:"error,omitempty"`
+}
+
+// analysisError carries the HTTP status to report for a failed submission.
+type analysisError struct {
+	status  int
+	message string
+}
+
+func (e *analysisError) Error() string {
+	return e.message
+}
+
+const (
+	idempotencyKeyHeader        = "Idempotency-Key"
+	idempotencyReplayedHeader   = "Idempotent-Replayed"
+	analyzeIdempotencyKeyPrefix = "idempotency:analyze:"
+	// Stored under the key while the first request is still being submitted
+	idempotencyPending = "pending"
+)
+
+// RequestAnalysis submits a new trading analysis request. With an
+// Idempotency-Key header, repeating the request with the same key returns the
+// task created by the first one instead of submitting a new analysis.
+// @Summary      Request a trading analysis
+// @Tags         trading
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Idempotency-Key  header    string           false  "Client-chosen key, scoped to the user"
+// @Param        body             body      AnalysisRequest  true   "Analysis request"
+// @Success      200              {object}  models.TradingAnalysisTask  "Replay of an earlier request with the same key, or a task the service already had"
+// @Success      202              {object}  models.TradingAnalysisTask
+// @Failure      400              {object}  map[string]interface{}
+// @Failure      409              {object}  map[string]string
+// @Failure      422              {object}  map[string]string
+// @Failure      429              {object}  map[string]interface{}
+// @Failure      502              {object}  map[string]string
+// @Failure      503              {object}  map[string]string
+// @Router       /api/v1/trading/analyze [post]
 func RequestAnalysis(c *gin.Context) {
 	var req AnalysisRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	// Keeps the body for the Idempotency-Key fingerprint
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validators.ErrorMessage(err)})
+		return
+	}
+	if err := resolveAnalysisDate(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// Checked again on submission; here the client is also told its options
+	llm := resolveLLMSettings(&req)
+	if err := checkLLMChoice(llm.Provider, llm.Models()...); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "allowed": allowedLLMProviders()})
+		return
+	}
+	if err := checkLLMBaseURL(llm.BaseURL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := checkTickerKnown(c.Request.Context(), req.Ticker); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from JWT context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
 
-	getStr := func(key string) string {
-		if req.LLMConfig == nil {
-			return ""
+	idempotencyKey := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+	if idempotencyKey == "" {
+		if !enforceAnalysisQuota(c, quotaCost(req)) {
+			return
 		}
-		if v, ok := req.LLMConfig[key]; ok {
-			if s, ok := v.(string); ok {
-				return s
-			}
+		task, existing, aerr := submitAnalysis(c.Request.Context(), userID.(uint), req)
+		if aerr != nil {
+			c.JSON(aerr.status, gin.H{"error": aerr.message})
+			return
 		}
-		return ""
+		c.JSON(submittedStatus(existing), task)
+		return
+	}
+	if len(idempotencyKey) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+		return
 	}
 
-	llmProvider := getStr("provider")
-	llmModel := getStr("quick_think_llm")
-	if llmModel == "" {
-		llmModel = getStr("deep_think_llm")
+	// Claim the key before submitting so concurrent retries can't both go through
+	ctx := c.Request.Context()
+	redisKey := fmt.Sprintf("%s%d:%s", analyzeIdempotencyKeyPrefix, userID, idempotencyKey)
+	ttl := config.AppConfig.Trading.IdempotencyTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	// The fingerprint is taken before defaults are filled in, so a retry of a
+	// request without a date still matches after the day changes
+	body, _ := c.Get(gin.BodyBytesKey)
+	fingerprint := requestFingerprint(body.([]byte))
+	claimed, err := global.RedisDB.SetNX(ctx, redisKey, fingerprint+":"+idempotencyPending, ttl).Result()
+	if err != nil {
+		respondRedisError(c, err)
+		return
+	}
+	if !claimed {
+		replayAnalysisRequest(c, redisKey, userID.(uint), fingerprint)
+		return
+	}
+	// Replays above don't count against the quota
+	if !enforceAnalysisQuota(c, quotaCost(req)) {
+		_ = global.RedisDB.Del(context.WithoutCancel(ctx), redisKey).Err()
+		return
+	}
+
+	task, existing, aerr := submitAnalysis(ctx, userID.(uint), req)
+	if aerr != nil {
+		// Let the client retry with the same key
+		_ = global.RedisDB.Del(context.WithoutCancel(ctx), redisKey).Err()
+		c.JSON(aerr.status, gin.H{"error": aerr.message})
+		return
+	}
+	if err := global.RedisDB.Set(context.WithoutCancel(ctx), redisKey, fingerprint+":"+task.TaskID, ttl).Err(); err != nil {
+		global.Logger.Error("Failed to store idempotency key", "task_id", task.TaskID, "error", err)
+	}
+
+	c.JSON(submittedStatus(existing), task)
+}
+
+// submittedStatus is the status answering a submission: 202 for a new task,
+// 200 when the trading service returned one that was already recorded.
+func submittedStatus(existing bool) int {
+	if existing {
+		return http.StatusOK
+	}
+	return http.StatusAccepted
+}
+
+// requestFingerprint identifies a request body for Idempotency-Key reuse
+// checks. Whitespace is ignored, so reformatting the JSON is not a new request.
+func requestFingerprint(body []byte) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err == nil {
+		body = compact.Bytes()
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayAnalysisRequest answers a request whose Idempotency-Key was already
+// used, returning the task created for it in its current state. The key holds
+// the first request's body fingerprint and its task ID ("pending" until the
+// task exists); a different body is rejected.
+func replayAnalysisRequest(c *gin.Context, redisKey string, userID uint, fingerprint string) {
+	value, err := global.RedisDB.Get(c.Request.Context(), redisKey).Result()
+	if err == redis.Nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still in progress"})
+		return
+	} else if err != nil {
+		respondRedisError(c, err)
+		return
+	}
+	storedFingerprint, taskID, _ := strings.Cut(value, ":")
+	if storedFingerprint != fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+		return
+	}
+	if taskID == idempotencyPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still in progress"})
+		return
+	}
+
+	var task models.TradingAnalysisTask
+	if err := global.DB.Where("task_id = ? AND user_id = ?", taskID, userID).
+		Preload("Decision").
+		First(&task).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.Header(idempotencyReplayedHeader, "true")
+	c.JSON(http.StatusOK, task)
+}
+
+// RequestBatchAnalysis submits one analysis per item. Items are processed
+// independently, so a failure is reported for that item without aborting the rest.
+// @Summary      Request several trading analyses
+// @Tags         trading
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      BatchAnalysisRequest  true  "Up to 20 analysis requests"
+// @Success      202   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]string
+// @Failure      429   {object}  map[string]interface{}
+// @Failure      502   {object}  map[string]interface{}
+// @Router       /api/v1/trading/analyze/batch [post]
+func RequestBatchAnalysis(c *gin.Context) {
+	var req BatchAnalysisRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validators.ErrorMessage(err)})
+		return
+	}
+	if len(req.Items) > maxBatchAnalysisItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("batch cannot exceed %d items", maxBatchAnalysisItems)})
+		return
+	}
+	for i := range req.Items {
+		if err := resolveAnalysisDate(&req.Items[i]); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("items[%d]: %v", i, err)})
+			return
+		}
+		if err := checkTickerKnown(c.Request.Context(), req.Items[i].Ticker); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("items[%d]: %v", i, err)})
+			return
+		}
 	}
-	llmBaseURL := getStr("base_url")
 
-	// Get user ID from JWT context
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
 		return
 	}
 
-	// Call Python trading service
-	jsonData, _ := json.Marshal(req)
-	resp, err := tradingHTTPClient.Post(
-		TRADING_SERVICE_URL+"/api/v1/analyze",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to call trading service: " + err.Error()})
+	if !enforceAnalysisQuota(c, quotaCost(req.Items...)) {
 		return
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	results := make([]BatchAnalysisResult, 0, len(req.Items))
+	submitted := 0
+	for i, item := range req.Items {
+		result := BatchAnalysisResult{Index: i, Ticker: item.Ticker, Date: item.Date}
+		task, existing, aerr := submitAnalysis(c.Request.Context(), userID.(uint), item)
+		if aerr != nil {
+			result.Error = aerr.message
+		} else {
+			result.Task = task
+			result.Existing = existing
+			submitted++
+		}
+		results = append(results, result)
+	}
 
-	if resp.StatusCode != http.StatusAccepted {
-		errMsg := extractTradingServiceError(body, resp.StatusCode)
-		c.JSON(http.StatusBadGateway, gin.H{"error": errMsg})
-		return
+	status := http.StatusAccepted
+	if submitted == 0 {
+		status = http.StatusBadGateway
 	}
+	c.JSON(status, gin.H{
+		"results":   results,
+		"submitted": submitted,
+		"failed":    len(results) - submitted,
+	})
+}
 
+// analysisLocation is the time zone that decides what "today" is for analysis
+// dates: trading.timezone, New York by default.
+func analysisLocation() *time.Location {
+	tz := config.AppConfig.Trading.Timezone
+	if tz == "" {
+		tz = "America/New_York"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		global.Logger.Warn("Invalid trading.timezone, using UTC", "timezone", tz, "error", err)
+		return time.UTC
+	}
+	return loc
+}
+
+// resolveAnalysisDate fills in today's date when the request has none and
+// rejects dates in the future, which have no market data yet. Unless
+// trading.nonTradingDays is allow, a missing date means the latest trading
+// day, and a given one must be a trading day (see checkMarketDay).
+func resolveAnalysisDate(req *AnalysisRequest) error {
+	now := time.Now().In(analysisLocation())
+	today := now.Format(validators.DateLayout)
+	if req.Date == "" {
+		if nonTradingDaysMode() != nonTradingDaysAllow {
+			now = previousTradingDay(now)
+		}
+		req.Date = now.Format(validators.DateLayout)
+		return nil
+	}
+	// Both are YYYY-MM-DD, so they compare chronologically as strings
+	if req.Date > today {
+		return fmt.Errorf("date %s is in the future (today is %s)", req.Date, today)
+	}
+	return checkMarketDay(req)
+}
+
+// submitAnalysis forwards a request to the Python trading service and records the
+// resulting task for the user. The service may answer a duplicate submission
+// with a task it already has; when that task is already recorded for the user
+// it is returned as is, with existing set, instead of a new row.
+func submitAnalysis(ctx context.Context, userID uint, req AnalysisRequest) (task *models.TradingAnalysisTask, existing bool, aerr *analysisError) {
+	if req.PresetID != nil {
+		if aerr := applyPreset(userID, &req); aerr != nil {
+			return nil, false, aerr
+		}
+	}
+
+	llm := resolveLLMSettings(&req)
+	if err := checkLLMChoice(llm.Provider, llm.Models()...); err != nil {
+		return nil, false, &analysisError{http.StatusBadRequest, err.Error()}
+	}
+	if err := checkLLMBaseURL(llm.BaseURL); err != nil {
+		return nil, false, &analysisError{http.StatusBadRequest, err.Error()}
+	}
+
+	var taskConfig *string
+	if req.Config != nil {
+		configJSON, err := json.Marshal(req.Config)
+		if err != nil {
+			return nil, false, &analysisError{http.StatusBadRequest, "invalid config: " + err.Error()}
+		}
+		configStr := string(configJSON)
+		taskConfig = &configStr
+	}
+
+	// Call Python trading service
 	var pythonResp PythonServiceResponse
-	if err := json.Unmarshal(body, &pythonResp); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse response: " + err.Error()})
-		return
+	if req.DryRun {
+		if !config.AppConfig.Trading.DryRunEnabled {
+			return nil, false, &analysisError{http.StatusBadRequest, "dry_run is not enabled on this server"}
+		}
+		pythonResp = PythonServiceResponse{TaskID: newDryRunTaskID(), Status: "pending"}
+	} else {
+		status, err := callTradingService(ctx, http.MethodPost, "/api/v1/analyze", pythonAnalysisRequest{
+			Ticker:    req.Ticker,
+			Date:      req.Date,
+			LLMConfig: llm.Config,
+			Config:    req.Config,
+		}, &pythonResp)
+		var serviceErr *tradingServiceError
+		switch {
+		case errors.As(err, &serviceErr):
+			return nil, false, &analysisError{http.StatusBadGateway, serviceErr.Message}
+		case errors.Is(err, errTradingCircuitOpen):
+			return nil, false, &analysisError{http.StatusServiceUnavailable, err.Error()}
+		case err != nil && status == 0:
+			return nil, false, &analysisError{http.StatusInternalServerError, "failed to call trading service: " + err.Error()}
+		case err != nil:
+			return nil, false, &analysisError{http.StatusInternalServerError, err.Error()}
+		case status != http.StatusAccepted:
+			return nil, false, &analysisError{http.StatusBadGateway, fmt.Sprintf("trading service returned status %d", status)}
+		}
 	}
 	if pythonResp.TaskID == "" {
-		c.JSON(http.StatusBadGateway, gin.H{"error": "trading service did not return a task_id"})
-		return
+		return nil, false, &analysisError{http.StatusBadGateway, "trading service did not return a task_id"}
 	}
 	if pythonResp.Status == "" {
 		pythonResp.Status = "pending"
 	}
 
 	// Create database record
-	task := models.TradingAnalysisTask{
-		UserID:       userID.(uint),
+	task = &models.TradingAnalysisTask{
+		UserID:       userID,
 		TaskID:       pythonResp.TaskID,
 		Ticker:       req.Ticker,
 		AnalysisDate: req.Date,
 		Status:       pythonResp.Status,
-		LLMProvider:  llmProvider,
-		LLMModel:     llmModel,
-		LLMBaseURL:   llmBaseURL,
+		Config:       taskConfig,
+		LLMProvider:  llm.Provider,
+		LLMModel:     llm.Model,
+		LLMBaseURL:   llm.BaseURL,
+		CallbackURL:  req.CallbackURL,
+		ScheduleID:   req.ScheduleID,
+		PresetID:     req.PresetID,
+		RetryOf:      req.RetryOf,
+		DryRun:       req.DryRun,
 	}
 
-	if err := global.DB.Create(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save task: " + err.Error()})
-		return
+	// Per-request callbacks are signed with the user's webhook secret
+	if task.CallbackURL != "" {
+		if _, err := ensureWebhookSubscription(userID); err != nil {
+			return nil, false, dbAnalysisError(err)
+		}
+	}
+
+	if err := global.DB.Create(task).Error; err != nil {
+		if db.IsUniqueViolation(err) {
+			return existingAnalysis(userID, task.TaskID)
+		}
+		return nil, false, dbAnalysisError(err)
 	}
 
-	c.JSON(http.StatusAccepted, task)
+	return task, false, nil
+}
+
+// existingAnalysis returns the recorded task behind a task_id the Python
+// service handed out again. A task_id recorded for another user, or one the
+// user deleted, cannot be reused and is reported as a service error.
+func existingAnalysis(userID uint, taskID string) (*models.TradingAnalysisTask, bool, *analysisError) {
+	var task models.TradingAnalysisTask
+	if err := global.DB.Where("task_id = ? AND user_id = ?", taskID, userID).
+		Preload("Decision").
+		First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			global.Logger.Error("Trading service reused a task_id that is not available", "task_id", taskID, "user_id", userID)
+			return nil, false, &analysisError{http.StatusBadGateway, "trading service returned a task_id that is already in use"}
+		}
+		return nil, false, dbAnalysisError(err)
+	}
+	return &task, true, nil
 }
 
 // GetAnalysisResult retrieves analysis result by task ID
+// @Summary      Get an analysis result
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        task_id  path      string  true  "Task ID"
+// @Success      200      {object}  models.TradingAnalysisTask  "While the task is active, Retry-After and poll_after_seconds give the polling interval"
+// @Failure      404      {object}  map[string]string
+// @Failure      502      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /api/v1/trading/analysis/{task_id} [get]
 func GetAnalysisResult(c *gin.Context) {
 	taskID := c.Param("task_id")
 
@@ -178,196 +743,661 @@ func GetAnalysisResult(c *gin.Context) {
 	if err := global.DB.Where("task_id = ? AND user_id = ?", taskID, userID).
 		Preload("Decision").
 		First(&task).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		} else {
+			respondDBError(c, err)
+		}
 		return
 	}
 
 	// If task is still processing, fetch latest status from Python service
-	if task.Status == "pending" || task.Status == "processing" {
-		resp, err := tradingHTTPClient.Get(TRADING_SERVICE_URL + "/api/v1/analysis/" + taskID)
-		if err != nil {
-			task.Status = "failed"
-			task.Error = "failed to reach trading service: " + err.Error()
-			global.DB.Save(&task)
-			c.JSON(http.StatusBadGateway, gin.H{"error": task.Error})
+	if isActiveTaskStatus(task.Status) {
+		ctx := c.Request.Context()
+		if err := refreshTask(ctx, &task); err != nil {
+			// The client went away; the task itself is fine
+			if ctx.Err() != nil {
+				return
+			}
+			// The service could not be reached, so the task may still be
+			// running; leave it for the reconciler or the callback
+			if errors.Is(err, errTradingCircuitOpen) {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+				return
+			}
+			global.Logger.Warn("Failed to refresh task", "task_id", task.TaskID, "error", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to reach trading service"})
 			return
 		}
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			task.Status = "failed"
-			task.Error = extractTradingServiceError(body, resp.StatusCode)
-			global.DB.Save(&task)
-			c.JSON(http.StatusOK, task)
-			return
+	// The reconciler refreshes active tasks once per interval, so polling
+	// more often than that cannot observe anything new
+	if isActiveTaskStatus(task.Status) {
+		task.PollAfterSeconds = int(math.Ceil(ReconcileInterval().Seconds()))
+		c.Header("Retry-After", strconv.Itoa(task.PollAfterSeconds))
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+func isActiveTaskStatus(status string) bool {
+	return status == "pending" || status == "processing"
+}
+
+// ReconcileInterval is how often active tasks are refreshed from the Python
+// service: trading.reconcileInterval, 15 seconds by default.
+func ReconcileInterval() time.Duration {
+	if interval := config.AppConfig.Trading.ReconcileInterval; interval > 0 {
+		return interval
+	}
+	return 15 * time.Second
+}
+
+// refreshTask fetches the latest state of an active task from the Python service
+// and saves it. It only returns an error when the service could not be reached;
+// every other outcome, including upstream failures, is recorded on the task.
+func refreshTask(ctx context.Context, task *models.TradingAnalysisTask) error {
+	if task.DryRun {
+		completeDryRun(task)
+		return nil
+	}
+
+	var pythonResp PythonServiceResponse
+	status, err := callTradingService(ctx, http.MethodGet, "/api/v1/analysis/"+url.PathEscape(task.TaskID), nil, &pythonResp)
+	if err != nil {
+		if status == 0 {
+			return err
 		}
+		task.Status = "failed"
+		task.Error = err.Error()
+		saveTask(task)
+		return nil
+	}
 
-		var pythonResp PythonServiceResponse
-		if err := json.Unmarshal(body, &pythonResp); err != nil {
-			task.Status = "failed"
-			task.Error = "failed to parse trading service response: " + err.Error()
-			global.DB.Save(&task)
-			c.JSON(http.StatusOK, task)
-			return
+	applyServiceResponse(task, &pythonResp)
+	return nil
+}
+
+// applyServiceResponse records the service's view of a task, including its
+// decision once completed, and saves it. Shared by polling and the push callback.
+func applyServiceResponse(task *models.TradingAnalysisTask, pythonResp *PythonServiceResponse) {
+	// Update task status
+	task.Status = pythonResp.Status
+
+	// Always surface latest analysis_report to the client (even mid-run)
+	if pythonResp.AnalysisReport != nil {
+		task.AnalysisReport = pythonResp.AnalysisReport
+	}
+	if pythonResp.KeyOutputs != nil {
+		task.KeyOutputs = pythonResp.KeyOutputs
+	}
+	if pythonResp.StageTimes != nil {
+		task.StageTimes = pythonResp.StageTimes
+	}
+
+	// If completed, save decision
+	if pythonResp.Status == "completed" && pythonResp.Decision != nil {
+		// Update task
+		if pythonResp.CompletedAt != "" {
+			completedAt, _ := time.Parse(time.RFC3339, pythonResp.CompletedAt)
+			completedAt = completedAt.UTC()
+			task.CompletedAt = &completedAt
 		}
+		task.ProcessingTimeSeconds = pythonResp.ProcessingTimeSeconds
 
-		// Update task status
-		task.Status = pythonResp.Status
+		// Create or update decision
+		action, _ := pythonResp.Decision["action"].(string)
+		confidence, _ := pythonResp.Decision["confidence"].(float64)
+		decision := models.TradingDecision{
+			TaskID:     task.TaskID,
+			Action:     action,
+			Confidence: confidence,
+		}
 
-		// Always surface latest analysis_report to the client (even mid-run)
+		// Save analysis report as JSON
 		if pythonResp.AnalysisReport != nil {
-			task.AnalysisReport = pythonResp.AnalysisReport
-		}
-		if pythonResp.KeyOutputs != nil {
-			task.KeyOutputs = pythonResp.KeyOutputs
+			reportJSON, _ := json.Marshal(pythonResp.AnalysisReport)
+			reportStr := string(reportJSON)
+			decision.AnalysisReport = &reportStr
 		}
-		if pythonResp.StageTimes != nil {
-			task.StageTimes = pythonResp.StageTimes
+
+		// Save raw decision
+		if rawDecision, ok := pythonResp.Decision["raw_decision"].(map[string]interface{}); ok {
+			rawJSON, _ := json.Marshal(rawDecision)
+			rawStr := string(rawJSON)
+			decision.RawDecision = &rawStr
 		}
 
-		// If completed, save decision
-		if pythonResp.Status == "completed" && pythonResp.Decision != nil {
-			// Update task
-			if pythonResp.CompletedAt != "" {
-				completedAt, _ := time.Parse(time.RFC3339, pythonResp.CompletedAt)
-				task.CompletedAt = &completedAt
-			}
-			task.ProcessingTimeSeconds = pythonResp.ProcessingTimeSeconds
+		task.Decision = &decision
+	}
 
-			// Create or update decision
-			decision := models.TradingDecision{
-				TaskID:     taskID,
-				Action:     pythonResp.Decision["action"].(string),
-				Confidence: pythonResp.Decision["confidence"].(float64),
-			}
+	if pythonResp.Status == "failed" {
+		task.Error = pythonResp.Error
+	}
 
-			// Save analysis report as JSON
-			if pythonResp.AnalysisReport != nil {
-				reportJSON, _ := json.Marshal(pythonResp.AnalysisReport)
-				reportStr := string(reportJSON)
-				decision.AnalysisReport = &reportStr
-			}
+	saveTask(task)
+}
 
-			// Save raw decision
-			if rawDecision, ok := pythonResp.Decision["raw_decision"].(map[string]interface{}); ok {
-				rawJSON, _ := json.Marshal(rawDecision)
-				rawStr := string(rawJSON)
-				decision.RawDecision = &rawStr
+// saveTask persists a task, adding its completion webhook to the outbox the
+// first time it reaches a terminal status. The webhook and the task's decision,
+// if any, are written in the same transaction so a task is never stored as
+// completed without them, and processing the same task twice (e.g. concurrent
+// polls) leaves a single decision row and counts the decision once.
+func saveTask(task *models.TradingAnalysisTask) {
+	firstCompletion := false
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if task.Decision != nil {
+			// Locks the task row, so only one writer sees the transition
+			res := tx.Model(&models.TradingAnalysisTask{}).
+				Where("id = ? AND status <> ?", task.ID, "completed").
+				Update("status", "completed")
+			if res.Error != nil {
+				return res.Error
 			}
+			firstCompletion = res.RowsAffected == 1
 
-			global.DB.Create(&decision)
-			task.Decision = &decision
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "task_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"updated_at", "deleted_at", "action", "confidence", "position_size", "analysis_report", "analysis_report_gz", "raw_decision"}),
+			}).Create(task.Decision).Error; err != nil {
+				return err
+			}
+		}
+		// webhook_status is only ever changed by conditional updates, so a
+		// stale copy of the task cannot reset it
+		if err := tx.Omit(clause.Associations, "webhook_status").Save(task).Error; err != nil {
+			return err
 		}
 
-		if pythonResp.Status == "failed" {
-			task.Error = pythonResp.Error
+		if isActiveTaskStatus(task.Status) {
+			return nil
 		}
+		return enqueueTaskWebhook(tx, task)
+	})
+	if err != nil {
+		global.Logger.Error("Failed to save trading task", "task_id", task.TaskID, "error", err)
+		return
+	}
+	if firstCompletion {
+		metrics.TradingDecisionsTotal.WithLabelValues(task.Decision.Action).Inc()
+	}
+}
 
-		global.DB.Save(&task)
+// ReconcileTasks refreshes every pending or processing task from the Python
+// service so that tasks progress (and webhooks fire) without client polling.
+func ReconcileTasks(ctx context.Context) {
+	var tasks []models.TradingAnalysisTask
+	if err := global.DB.WithContext(ctx).
+		Where("status IN ?", []string{"pending", "processing"}).
+		Order("created_at").
+		Limit(100).
+		Find(&tasks).Error; err != nil {
+		global.Logger.Warn("Failed to load tasks to reconcile", "error", err)
+		return
 	}
 
-	c.JSON(http.StatusOK, task)
+	for i := range tasks {
+		if ctx.Err() != nil {
+			return
+		}
+		// Unreachable service: leave the task as is and retry on the next tick
+		if err := refreshTask(ctx, &tasks[i]); err != nil {
+			global.Logger.Warn("Failed to reconcile task", "task_id", tasks[i].TaskID, "error", err)
+			return
+		}
+	}
 }
 
-// ListUserAnalyses lists all analysis tasks for the current user
+// ListUserAnalyses lists all analysis tasks for the current user, as JSON or,
+// when the client asks for text/csv, as CSV in the export layout
+// @Summary      List the current user's analyses
+// @Tags         trading
+// @Produce      json
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        date_from  query     string  false  "Earliest analysis date (YYYY-MM-DD)"
+// @Param        date_to    query     string  false  "Latest analysis date (YYYY-MM-DD)"
+// @Param        page       query     int     false  "Page (default 1)"
+// @Param        page_size  query     int     false  "Page size (default 20, max 100)"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]string
+// @Failure      406        {object}  map[string]interface{}
+// @Router       /api/v1/trading/analyses [get]
 func ListUserAnalyses(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
 		return
 	}
+	dates, ok := bindAnalysisDateRange(c)
+	if !ok {
+		return
+	}
+
+	offset, limit := pagination.Parse(c)
+	query := dates.apply(userAnalysesQuery(global.DB, userID))
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
 
 	var tasks []models.TradingAnalysisTask
-	result := global.DB.Where("user_id = ?", userID).
+	result := query.Session(&gorm.Session{}).
 		Preload("Decision").
 		Order("created_at DESC").
-		Limit(20).
+		Offset(offset).
+		Limit(limit).
 		Find(&tasks)
 
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		respondDBError(c, result.Error)
 		return
 	}
 
+	if middlewares.NegotiatedType(c) == middlewares.MediaCSV {
+		writeAnalysesCSV(c, tasks, total)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"tasks": tasks,
-		"total": len(tasks),
+		"tasks":      tasks,
+		"total":      total,
+		"pagination": pagination.NewMeta(offset, limit, total),
 	})
 }
 
-// GetAnalysisStats returns statistics about user's trading analyses
-func GetAnalysisStats(c *gin.Context) {
+// userAnalysesQuery selects the analyses owned by userID. Shared by the
+// listing and the export so that both return the same set.
+func userAnalysesQuery(db *gorm.DB, userID interface{}) *gorm.DB {
+	return db.Model(&models.TradingAnalysisTask{}).Where("trading_analysis_tasks.user_id = ?", userID)
+}
+
+// AnalysisDateRange filters analyses by their analysis date. Both ends are
+// inclusive and optional.
+type AnalysisDateRange struct {
+	DateFrom string `form:"date_from" binding:"omitempty,isodate"`
+	DateTo   string `form:"date_to" binding:"omitempty,isodate"`
+}
+
+// bindAnalysisDateRange reads date_from and date_to from the query. On invalid
+// input it writes a 400 and returns false.
+func bindAnalysisDateRange(c *gin.Context) (AnalysisDateRange, bool) {
+	var dates AnalysisDateRange
+	if err := c.ShouldBindQuery(&dates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validators.ErrorMessage(err)})
+		return dates, false
+	}
+	if dates.DateFrom != "" && dates.DateTo != "" && dates.DateFrom > dates.DateTo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date_from must not be after date_to"})
+		return dates, false
+	}
+	return dates, true
+}
+
+// apply restricts query to the range. analysis_date is stored as YYYY-MM-DD,
+// like the validated bounds, so string comparison orders it chronologically.
+func (r AnalysisDateRange) apply(query *gorm.DB) *gorm.DB {
+	if r.DateFrom != "" {
+		query = query.Where("trading_analysis_tasks.analysis_date >= ?", r.DateFrom)
+	}
+	if r.DateTo != "" {
+		query = query.Where("trading_analysis_tasks.analysis_date <= ?", r.DateTo)
+	}
+	return query
+}
+
+// DeleteAnalyses soft-deletes the current user's analyses and their decisions,
+// optionally only those created before a date
+// @Summary      Delete the current user's analyses
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        before  query     string  false  "Only analyses created before this date (YYYY-MM-DD)"
+// @Success      200     {object}  map[string]int64  "deleted"
+// @Failure      400     {object}  map[string]string
+// @Router       /api/v1/trading/analyses [delete]
+func DeleteAnalyses(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
 		return
 	}
 
-	var total int64
-	var completed int64
-	var failed int64
+	var beforeDate *time.Time
+	if before := c.Query("before"); before != "" {
+		parsed, err := time.Parse(validators.DateLayout, before)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "before must be a date in YYYY-MM-DD format"})
+			return
+		}
+		beforeDate = &parsed
+	}
 
-	global.DB.Model(&models.TradingAnalysisTask{}).Where("user_id = ?", userID).Count(&total)
-	global.DB.Model(&models.TradingAnalysisTask{}).Where("user_id = ? AND status = ?", userID, "completed").Count(&completed)
-	global.DB.Model(&models.TradingAnalysisTask{}).Where("user_id = ? AND status = ?", userID, "failed").Count(&failed)
+	deleted, err := deleteTasks(func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ?", userID)
+		if beforeDate != nil {
+			db = db.Where("created_at < ?", *beforeDate)
+		}
+		return db
+	})
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
 
-	// Count decisions by action
-	var buyCount, sellCount, holdCount int64
-	global.DB.Table("trading_decisions").
-		Joins("JOIN trading_analysis_tasks ON trading_decisions.task_id = trading_analysis_tasks.task_id").
-		Where("trading_analysis_tasks.user_id = ? AND trading_decisions.action = ?", userID, "BUY").
-		Count(&buyCount)
+// DeleteAnalysis soft-deletes one of the current user's analyses and its decision
+// @Summary      Delete an analysis
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        task_id  path      string  true  "Task ID"
+// @Success      200      {object}  map[string]int64  "deleted"
+// @Failure      404      {object}  map[string]string
+// @Router       /api/v1/trading/analysis/{task_id} [delete]
+func DeleteAnalysis(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
 
-	global.DB.Table("trading_decisions").
-		Joins("JOIN trading_analysis_tasks ON trading_decisions.task_id = trading_analysis_tasks.task_id").
-		Where("trading_analysis_tasks.user_id = ? AND trading_decisions.action = ?", userID, "SELL").
-		Count(&sellCount)
+	deleted, err := deleteTasks(func(db *gorm.DB) *gorm.DB {
+		return db.Where("task_id = ? AND user_id = ?", c.Param("task_id"), userID)
+	})
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
+// deleteTasks soft-deletes the tasks selected by scope together with their
+// decisions, in one transaction. It returns the number of tasks deleted.
+func deleteTasks(scope func(*gorm.DB) *gorm.DB) (int64, error) {
+	var deleted int64
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		var taskIDs []string
+		if err := tx.Model(&models.TradingAnalysisTask{}).Scopes(scope).
+			Pluck("task_id", &taskIDs).Error; err != nil {
+			return err
+		}
+		if len(taskIDs) == 0 {
+			return nil
+		}
+		if err := tx.Where("task_id IN ?", taskIDs).Delete(&models.TradingDecision{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("task_id IN ?", taskIDs).Delete(&models.TradingAnalysisTask{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+// CompareEntry summarises one completed analysis in a CompareAnalyses response.
+type CompareEntry struct {
+	TaskID                string            `json:"task_id"`
+	AnalysisDate          string            `json:"analysis_date"`
+	Action                string            `json:"action"`
+	Confidence            float64           `json:"confidence"`
+	PositionSize          int               `json:"position_size,omitempty"`
+	ProcessingTimeSeconds float64           `json:"processing_time_seconds,omitempty"`
+	Proposals             map[string]string `json:"proposals,omitempty"` // transaction proposal per agent stage
+}
+
+// CompareAnalyses shows how the user's recommendation for a ticker evolved across analysis dates
+// @Summary      Compare a ticker's analyses over time
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ticker     query     string  true   "Ticker symbol"
+// @Param        page       query     int     false  "Page (default 1)"
+// @Param        page_size  query     int     false  "Page size (default 20, max 100)"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]string
+// @Router       /api/v1/trading/compare [get]
+func CompareAnalyses(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	ticker := strings.ToUpper(strings.TrimSpace(c.Query("ticker")))
+	if ticker == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ticker is required"})
+		return
+	}
+
+	offset, limit := pagination.Parse(c)
+
+	// Only analyses with a decision are compared, so the summary and the
+	// pages count the same rows
+	completed := global.DB.Model(&models.TradingAnalysisTask{}).
+		Joins("JOIN trading_decisions ON trading_decisions.task_id = trading_analysis_tasks.task_id AND trading_decisions.deleted_at IS NULL").
+		Where("trading_analysis_tasks.user_id = ? AND UPPER(trading_analysis_tasks.ticker) = ? AND trading_analysis_tasks.status = ?",
+			userID, ticker, "completed").
+		Order("trading_analysis_tasks.analysis_date ASC").
+		Order("trading_analysis_tasks.id ASC")
+
+	// Action history across every completed analysis, used for the flip summary
+	var actions []string
+	if err := completed.Session(&gorm.Session{}).
+		Pluck("trading_decisions.action", &actions).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	var tasks []models.TradingAnalysisTask
+	if err := completed.Session(&gorm.Session{}).
+		Preload("Decision").
+		Offset(offset).
+		Limit(limit).
+		Find(&tasks).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	entries := make([]CompareEntry, 0, len(tasks))
+	for _, task := range tasks {
+		if task.Decision == nil {
+			continue
+		}
+		entries = append(entries, CompareEntry{
+			TaskID:                task.TaskID,
+			AnalysisDate:          task.AnalysisDate,
+			Action:                task.Decision.Action,
+			Confidence:            task.Decision.Confidence,
+			PositionSize:          task.Decision.PositionSize,
+			ProcessingTimeSeconds: task.ProcessingTimeSeconds,
+			Proposals:             extractProposals(task.Decision.AnalysisReport),
+		})
+	}
+
+	flips := 0
+	actionCounts := map[string]int{}
+	for i, action := range actions {
+		actionCounts[action]++
+		if i > 0 && action != actions[i-1] {
+			flips++
+		}
+	}
+	summary := gin.H{
+		"total":        len(actions),
+		"action_flips": flips,
+		"actions":      actionCounts,
+	}
+	if len(actions) > 0 {
+		summary["first_action"] = actions[0]
+		summary["latest_action"] = actions[len(actions)-1]
+	}
 
-	global.DB.Table("trading_decisions").
+	c.JSON(http.StatusOK, gin.H{
+		"ticker":     ticker,
+		"analyses":   entries,
+		"summary":    summary,
+		"pagination": pagination.NewMeta(offset, limit, int64(len(actions))),
+	})
+}
+
+// extractProposals pulls the per-stage transaction proposals out of a stored
+// analysis report (the "__key_outputs" section written by the Python service).
+func extractProposals(report *string) map[string]string {
+	if report == nil {
+		return nil
+	}
+	var parsed struct {
+		KeyOutputs map[string]struct {
+			TransactionProposal string `json:"transaction_proposal"`
+		} `json:"__key_outputs"`
+	}
+	if err := json.Unmarshal([]byte(*report), &parsed); err != nil {
+		return nil
+	}
+
+	proposals := map[string]string{}
+	for stage, out := range parsed.KeyOutputs {
+		if out.TransactionProposal != "" {
+			proposals[stage] = out.TransactionProposal
+		}
+	}
+	if len(proposals) == 0 {
+		return nil
+	}
+	return proposals
+}
+
+// GetAnalysisStats returns statistics about user's trading analyses
+// @Summary      Get analysis statistics for the current user
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Router       /api/v1/trading/stats [get]
+func GetAnalysisStats(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	// One pass over the user's tasks and one over their decisions; AVG/MAX are
+	// NULL (nil) when nothing matches
+	var tasks struct {
+		Total                int64
+		Completed            int64
+		Failed               int64
+		AvgProcessingSeconds *float64
+		MaxProcessingSeconds *float64
+	}
+	if err := global.DB.Model(&models.TradingAnalysisTask{}).
+		Where("user_id = ?", userID).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+			COUNT(*) FILTER (WHERE status = 'failed') AS failed,
+			AVG(processing_time_seconds) FILTER (WHERE status = 'completed') AS avg_processing_seconds,
+			MAX(processing_time_seconds) FILTER (WHERE status = 'completed') AS max_processing_seconds`).
+		Scan(&tasks).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	var decisions struct {
+		Buy           int64
+		Sell          int64
+		Hold          int64
+		AvgConfidence *float64
+	}
+	if err := global.DB.Model(&models.TradingDecision{}).
 		Joins("JOIN trading_analysis_tasks ON trading_decisions.task_id = trading_analysis_tasks.task_id").
-		Where("trading_analysis_tasks.user_id = ? AND trading_decisions.action = ?", userID, "HOLD").
-		Count(&holdCount)
+		Where("trading_analysis_tasks.user_id = ?", userID).
+		Select(`COUNT(*) FILTER (WHERE trading_decisions.action = 'BUY') AS buy,
+			COUNT(*) FILTER (WHERE trading_decisions.action = 'SELL') AS sell,
+			COUNT(*) FILTER (WHERE trading_decisions.action = 'HOLD') AS hold,
+			AVG(trading_decisions.confidence) AS avg_confidence`).
+		Scan(&decisions).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	quota, err := analysisQuota(userID, c.GetString("role"))
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"total_analyses": total,
-		"completed":      completed,
-		"failed":         failed,
-		"pending":        total - completed - failed,
+		"total_analyses": tasks.Total,
+		"completed":      tasks.Completed,
+		"failed":         tasks.Failed,
+		"pending":        tasks.Total - tasks.Completed - tasks.Failed,
 		"decisions": gin.H{
-			"buy":  buyCount,
-			"sell": sellCount,
-			"hold": holdCount,
+			"buy":  decisions.Buy,
+			"sell": decisions.Sell,
+			"hold": decisions.Hold,
 		},
+		"avg_processing_time_seconds": tasks.AvgProcessingSeconds,
+		"max_processing_time_seconds": tasks.MaxProcessingSeconds,
+		"avg_confidence":              decisions.AvgConfidence,
+		"quota":                       quota,
 	})
 }
 
-// CheckServiceHealth checks if the Python trading service is available
+// CheckServiceHealth checks if the Python trading service is available and
+// reports the round-trip latency and the circuit breaker state. The check is
+// bounded by trading.healthTimeout so a hung service cannot hold the request open.
+// @Summary      Check the trading service health
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      503  {object}  map[string]interface{}
+// @Router       /api/v1/trading/health [get]
 func CheckServiceHealth(c *gin.Context) {
-	resp, err := http.Get(TRADING_SERVICE_URL + "/health")
-	if err != nil {
+	timeout := config.AppConfig.Trading.HealthTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	var healthResp map[string]interface{}
+	start := time.Now()
+	status, err := callTradingService(ctx, http.MethodGet, "/health", nil, &healthResp)
+	latency := time.Since(start).Milliseconds()
+	if status == 0 {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "unavailable",
-			"message": fmt.Sprintf("trading service is down: %v", err),
+			"status":          "unavailable",
+			"latency_ms":      latency,
+			"message":         fmt.Sprintf("trading service is down: %v", err),
+			"circuit_breaker": tradingBreaker.stateName(),
 		})
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if status != http.StatusOK {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "unavailable",
-			"message": "trading service returned non-200 status",
+			"status":          "unavailable",
+			"latency_ms":      latency,
+			"message":         fmt.Sprintf("trading service returned status %d", status),
+			"trading_service": healthResp,
+			"circuit_breaker": tradingBreaker.stateName(),
 		})
 		return
 	}
 
-	body, _ := io.ReadAll(resp.Body)
-	var healthResp map[string]interface{}
-	json.Unmarshal(body, &healthResp)
-
 	c.JSON(http.StatusOK, gin.H{
 		"status":          "healthy",
+		"latency_ms":      latency,
 		"trading_service": healthResp,
+		"circuit_breaker": tradingBreaker.stateName(),
 	})
 }
@@ -0,0 +1,486 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/metrics"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/gorm"
+)
+
+// stubTradingService points callTradingService at a test server running h,
+// with a fresh circuit breaker. Call it after testutil.Config.
+func stubTradingService(t *testing.T, h http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(func() {
+		srv.Close()
+		tradingBreaker = circuitBreaker{}
+	})
+	config.AppConfig.Trading.ServiceURL = srv.URL
+	tradingBreaker = circuitBreaker{}
+	return srv
+}
+
+// asUser stands in for the auth middleware, authenticating every request as
+// the given user.
+func asUser(id uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", id)
+		c.Next()
+	}
+}
+
+// createTask stores a task for user 1, with a decision when action is set.
+func createTask(t *testing.T, db *gorm.DB, taskID, date, status, action string) {
+	t.Helper()
+	task := models.TradingAnalysisTask{UserID: 1, TaskID: taskID, Ticker: "AAPL", AnalysisDate: date, Status: status}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	if action != "" {
+		if err := db.Create(&models.TradingDecision{TaskID: taskID, Action: action}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCompareAnalysesPagesOnlyDecidedAnalyses(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	createTask(t, db, "t1", "2024-01-02", "completed", "BUY")
+	createTask(t, db, "t2", "2024-01-03", "completed", "") // no decision stored
+	createTask(t, db, "t3", "2024-01-03", "completed", "SELL")
+	createTask(t, db, "t4", "2024-01-03", "completed", "HOLD")
+	createTask(t, db, "t5", "2024-01-04", "failed", "")
+
+	r := gin.New()
+	r.GET("/compare", asUser(1), CompareAnalyses)
+
+	var taskIDs []string
+	for page := 1; page <= 4; page++ {
+		w := testutil.Do(r, http.MethodGet, "/compare?ticker=aapl&page_size=1&page="+strconv.Itoa(page), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("page %d: status %d: %s", page, w.Code, w.Body)
+		}
+		var resp struct {
+			Analyses   []CompareEntry      `json:"analyses"`
+			Summary    struct{ Total int } `json:"summary"`
+			Pagination struct {
+				Total int64 `json:"total"`
+			} `json:"pagination"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Summary.Total != 3 || resp.Pagination.Total != 3 {
+			t.Fatalf("page %d: summary total %d, pagination total %d, want 3 and 3",
+				page, resp.Summary.Total, resp.Pagination.Total)
+		}
+		if page == 4 {
+			if len(resp.Analyses) != 0 {
+				t.Fatalf("page 4 has %d analyses, want none", len(resp.Analyses))
+			}
+			break
+		}
+		if len(resp.Analyses) != 1 {
+			t.Fatalf("page %d has %d analyses, want 1", page, len(resp.Analyses))
+		}
+		taskIDs = append(taskIDs, resp.Analyses[0].TaskID)
+	}
+
+	// Analyses on the same date keep their creation order across pages
+	if want := []string{"t1", "t3", "t4"}; !slices.Equal(taskIDs, want) {
+		t.Fatalf("paged task IDs = %v, want %v", taskIDs, want)
+	}
+}
+
+func TestSaveTaskProcessesCompletionOnce(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	task := models.TradingAnalysisTask{UserID: 1, TaskID: "t1", Ticker: "AAPL", AnalysisDate: "2024-01-02",
+		Status: "processing", CallbackURL: "https://example.com/hook"}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	decided := promtest.ToFloat64(metrics.TradingDecisionsTotal.WithLabelValues("BUY"))
+
+	// The push callback and a poll deliver the same completion at once, and
+	// the reconciler replays it later
+	completion := func() {
+		var stale models.TradingAnalysisTask
+		if err := db.First(&stale, task.ID).Error; err != nil {
+			t.Error(err)
+			return
+		}
+		stale.Status = "processing"
+		applyServiceResponse(&stale, &PythonServiceResponse{
+			TaskID:   "t1",
+			Status:   "completed",
+			Decision: map[string]interface{}{"action": "BUY", "confidence": 0.8},
+		})
+	}
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			completion()
+		}()
+	}
+	wg.Wait()
+	completion()
+
+	var decisions, events int64
+	db.Model(&models.TradingDecision{}).Where("task_id = ?", "t1").Count(&decisions)
+	db.Model(&models.OutboxEvent{}).Where("task_id = ?", "t1").Count(&events)
+	if decisions != 1 || events != 1 {
+		t.Fatalf("%d decisions and %d outbox events, want 1 and 1", decisions, events)
+	}
+	if n := promtest.ToFloat64(metrics.TradingDecisionsTotal.WithLabelValues("BUY")) - decided; n != 1 {
+		t.Fatalf("decision counted %v times, want once", n)
+	}
+}
+
+func TestGetAnalysisResultRepeatedPollsStoreOneDecision(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	createTask(t, db, "t1", "2024-01-02", "processing", "")
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"task_id":"t1","status":"completed","decision":{"action":"SELL","confidence":0.6}}`))
+	})
+
+	r := gin.New()
+	r.GET("/analysis/:task_id", asUser(1), GetAnalysisResult)
+	getConcurrently(t, r, "/analysis/t1", 5)
+	getConcurrently(t, r, "/analysis/t1", 2)
+
+	var decisions []models.TradingDecision
+	if err := db.Where("task_id = ?", "t1").Find(&decisions).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || decisions[0].Action != "SELL" {
+		t.Fatalf("decisions = %+v, want a single SELL", decisions)
+	}
+}
+
+func TestGetAnalysisResultLeavesTaskActiveWhenServiceIsUnreachable(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	createTask(t, db, "t1", "2024-01-02", "processing", "")
+	srv := stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {})
+	srv.Close()
+
+	r := gin.New()
+	r.GET("/analysis/:task_id", asUser(1), GetAnalysisResult)
+	if w := testutil.Do(r, http.MethodGet, "/analysis/t1", ""); w.Code != http.StatusBadGateway {
+		t.Fatalf("status %d, want 502: %s", w.Code, w.Body)
+	}
+
+	var task models.TradingAnalysisTask
+	if err := db.Where("task_id = ?", "t1").First(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != "processing" || task.Error != "" {
+		t.Fatalf("task status %q, error %q, want it left processing", task.Status, task.Error)
+	}
+	var events int64
+	db.Model(&models.OutboxEvent{}).Where("task_id = ?", "t1").Count(&events)
+	if events != 0 {
+		t.Fatalf("%d outbox events, want none", events)
+	}
+}
+
+func TestCallTradingServiceStopsWhenContextIsCancelled(t *testing.T) {
+	testutil.Config(t)
+	received := make(chan struct{})
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-r.Context().Done() // never answers
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		status, err := callTradingService(ctx, http.MethodGet, "/api/v1/analysis/t1", nil, nil)
+		if status != 0 {
+			t.Errorf("status = %d, want 0", status)
+		}
+		done <- err
+	}()
+
+	<-received
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("callTradingService did not return after its context was cancelled")
+	}
+	if n := promtest.ToFloat64(metrics.TradingCallsInFlight); n != 0 {
+		t.Fatalf("%v calls still in flight, want the slot released", n)
+	}
+	if tradingBreaker.failures != 0 {
+		t.Fatal("a cancelled call counted as a trading service failure")
+	}
+}
+
+func TestRequestAnalysisIdempotencyKey(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	var submitted atomic.Int64
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		n := submitted.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, `{"task_id":"t%d","status":"pending"}`, n)
+	})
+
+	r := gin.New()
+	r.POST("/analyze", asUser(1), RequestAnalysis)
+	post := func(key, body string) *httptest.ResponseRecorder {
+		return testutil.Do(r, http.MethodPost, "/analyze", body, "Idempotency-Key", key)
+	}
+
+	if w := post("k1", `{"ticker":"AAPL","date":"2024-01-02"}`); w.Code != http.StatusAccepted {
+		t.Fatalf("first request: status %d: %s", w.Code, w.Body)
+	}
+	// Reformatted, the body is still the same request
+	w := post("k1", `{ "ticker": "AAPL", "date": "2024-01-02" }`)
+	if w.Code != http.StatusOK || w.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Fatalf("retry: status %d, %s %q: %s", w.Code, idempotencyReplayedHeader, w.Header().Get(idempotencyReplayedHeader), w.Body)
+	}
+	if w := post("k1", `{"ticker":"MSFT","date":"2024-01-02"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("different body with the same key: status %d, want 422", w.Code)
+	}
+	// A key that holds no fingerprint matches no request
+	if err := global.RedisDB.Set(context.Background(), analyzeIdempotencyKeyPrefix+"1:k2", "t1", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if w := post("k2", `{"ticker":"AAPL","date":"2024-01-02"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("key without a fingerprint: status %d, want 422", w.Code)
+	}
+
+	var tasks int64
+	db.Model(&models.TradingAnalysisTask{}).Count(&tasks)
+	if tasks != 1 || submitted.Load() != 1 {
+		t.Fatalf("%d tasks and %d submissions, want 1 and 1", tasks, submitted.Load())
+	}
+}
+
+func TestRequestAnalysisIdempotencyKeyWithRedisDown(t *testing.T) {
+	testutil.Config(t)
+	testutil.DB(t)
+	testutil.Redis(t).Close()
+
+	r := gin.New()
+	r.POST("/analyze", asUser(1), RequestAnalysis)
+	w := testutil.Do(r, http.MethodPost, "/analyze", `{"ticker":"AAPL","date":"2024-01-02"}`, "Idempotency-Key", "k1")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503: %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "dial") || strings.Contains(w.Body.String(), "127.0.0.1") {
+		t.Fatalf("response leaks the Redis error: %s", w.Body)
+	}
+}
+
+func TestRequestAnalysisIdempotencyKeyAcrossMidnight(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"task_id":"t1","status":"pending"}`))
+	})
+
+	r := gin.New()
+	r.POST("/analyze", asUser(1), RequestAnalysis)
+	const body = `{"ticker":"AAPL"}`
+	if w := testutil.Do(r, http.MethodPost, "/analyze", body, "Idempotency-Key", "k1"); w.Code != http.StatusAccepted {
+		t.Fatalf("first request: status %d: %s", w.Code, w.Body)
+	}
+	// The task was dated yesterday by the time the client retries
+	if err := db.Model(&models.TradingAnalysisTask{}).Where("task_id = ?", "t1").
+		Update("analysis_date", "2000-01-01").Error; err != nil {
+		t.Fatal(err)
+	}
+	if w := testutil.Do(r, http.MethodPost, "/analyze", body, "Idempotency-Key", "k1"); w.Code != http.StatusOK {
+		t.Fatalf("retry without a date: status %d, want 200: %s", w.Code, w.Body)
+	}
+}
+
+func TestRequestAnalysisRejectsUnlistedLLMBaseURL(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.Trading.AllowedLLMs = map[string][]string{"openai": nil}
+	conf.Trading.AllowedLLMBaseURLs = []string{"https://api.openai.com/v1"}
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	var submitted atomic.Int64
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		n := submitted.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, `{"task_id":"t%d","status":"pending"}`, n)
+	})
+
+	r := gin.New()
+	r.POST("/analyze", asUser(1), RequestAnalysis)
+	for _, body := range []string{
+		`{"ticker":"AAPL","date":"2024-01-02","llm_base_url":"https://attacker.example/v1"}`,
+		`{"ticker":"AAPL","date":"2024-01-02","llm_config":{"base_url":"http://169.254.169.254/"}}`,
+	} {
+		if w := testutil.Do(r, http.MethodPost, "/analyze", body); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d, want 400: %s", body, w.Code, w.Body)
+		}
+	}
+	const listed = `{"ticker":"AAPL","date":"2024-01-02","llm_base_url":"https://api.openai.com/v1/"}`
+	if w := testutil.Do(r, http.MethodPost, "/analyze", listed); w.Code != http.StatusAccepted {
+		t.Fatalf("listed base URL: status %d, want 202: %s", w.Code, w.Body)
+	}
+
+	var tasks int64
+	db.Model(&models.TradingAnalysisTask{}).Count(&tasks)
+	if tasks != 1 || submitted.Load() != 1 {
+		t.Fatalf("%d tasks and %d submissions, want 1 and 1", tasks, submitted.Load())
+	}
+}
+
+func TestRequestAnalysisReusedTaskID(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	// The service answers a duplicate submission with the task it already has
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"task_id":"t1","status":"pending"}`))
+	})
+
+	r := gin.New()
+	r.POST("/analyze", asUser(1), RequestAnalysis)
+	const body = `{"ticker":"AAPL","date":"2024-01-02"}`
+	if w := testutil.Do(r, http.MethodPost, "/analyze", body); w.Code != http.StatusAccepted {
+		t.Fatalf("first submission: status %d, want 202: %s", w.Code, w.Body)
+	}
+	w := testutil.Do(r, http.MethodPost, "/analyze", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("second submission: status %d, want 200 with the existing task: %s", w.Code, w.Body)
+	}
+	var task models.TradingAnalysisTask
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.TaskID != "t1" {
+		t.Fatalf("returned task %q, want t1", task.TaskID)
+	}
+
+	var rows int64
+	db.Model(&models.TradingAnalysisTask{}).Count(&rows)
+	if rows != 1 {
+		t.Fatalf("%d task rows, want 1", rows)
+	}
+}
+
+func TestRequestAnalysisTaskIDOfAnotherUser(t *testing.T) {
+	testutil.Config(t)
+	testutil.DB(t)
+	testutil.Redis(t)
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"task_id":"t1","status":"pending"}`))
+	})
+
+	r := gin.New()
+	r.POST("/analyze/1", asUser(1), RequestAnalysis)
+	r.POST("/analyze/2", asUser(2), RequestAnalysis)
+	const body = `{"ticker":"AAPL","date":"2024-01-02"}`
+	if w := testutil.Do(r, http.MethodPost, "/analyze/1", body); w.Code != http.StatusAccepted {
+		t.Fatalf("user 1: status %d, want 202: %s", w.Code, w.Body)
+	}
+	// Never hands one user's task to another
+	if w := testutil.Do(r, http.MethodPost, "/analyze/2", body); w.Code != http.StatusBadGateway {
+		t.Fatalf("user 2 given user 1's task_id: status %d, want 502: %s", w.Code, w.Body)
+	}
+}
+
+func TestRetryAnalysisConcurrentRetriesSubmitOnce(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	createTask(t, db, "t1", "2024-01-02", "failed", "")
+	var submitted atomic.Int64
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		n := submitted.Add(1)
+		time.Sleep(20 * time.Millisecond) // keep the first retry in flight
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, `{"task_id":"r%d","status":"pending"}`, n)
+	})
+
+	r := gin.New()
+	r.POST("/analysis/:task_id/retry", asUser(1), RetryAnalysis)
+	codes := make([]int, 5)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = testutil.Do(r, http.MethodPost, "/analysis/t1/retry", "").Code
+		}()
+	}
+	wg.Wait()
+
+	slices.Sort(codes)
+	if want := []int{202, 409, 409, 409, 409}; !slices.Equal(codes, want) {
+		t.Fatalf("statuses = %v, want %v", codes, want)
+	}
+	var retries int64
+	db.Model(&models.TradingAnalysisTask{}).Where("retry_of = ?", "t1").Count(&retries)
+	if retries != 1 || submitted.Load() != 1 {
+		t.Fatalf("%d retries and %d submissions, want 1 and 1", retries, submitted.Load())
+	}
+}
+
+func TestRetryAnalysisReleasesClaimWhenSubmissionFails(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	createTask(t, db, "t1", "2024-01-02", "failed", "")
+	var fail atomic.Bool
+	fail.Store(true)
+	stubTradingService(t, func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"detail":"bad request"}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"task_id":"r1","status":"pending"}`))
+	})
+
+	r := gin.New()
+	r.POST("/analysis/:task_id/retry", asUser(1), RetryAnalysis)
+	if w := testutil.Do(r, http.MethodPost, "/analysis/t1/retry", ""); w.Code == http.StatusAccepted {
+		t.Fatalf("failed submission: status %d, want an error", w.Code)
+	}
+	fail.Store(false)
+	if w := testutil.Do(r, http.MethodPost, "/analysis/t1/retry", ""); w.Code != http.StatusAccepted {
+		t.Fatalf("retry after a failed submission: status %d, want 202: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+)
+
+// maxDebugBodyBytes bounds each body logged by trading.debugLogging.
+const maxDebugBodyBytes = 4096
+
+// sensitiveKeySuffixes mark JSON keys whose values are redacted from debug
+// logs, such as the API keys clients may pass in llm_config. Suffixes rather
+// than substrings, so that fields like key_outputs and max_tokens are kept.
+var sensitiveKeySuffixes = []string{"key", "secret", "token", "password", "authorization", "credentials"}
+
+// tradingDebugEnabled reports whether trading service payloads should be
+// logged: trading.debugLogging must be set and the logger at debug level, so
+// payloads never reach an info-level production log.
+func tradingDebugEnabled(ctx context.Context) bool {
+	return config.AppConfig.Trading.DebugLogging && global.Logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// logTradingExchange logs one call to the Python service with its request and
+// response bodies, redacted and truncated.
+func logTradingExchange(ctx context.Context, method, path string, reqBody []byte, status int, respBody []byte, elapsed time.Duration, err error) {
+	attrs := []any{
+		"method", method,
+		"path", path,
+		"status", status,
+		"duration_ms", elapsed.Milliseconds(),
+		"request_body", debugBody(reqBody),
+		"response_body", debugBody(respBody),
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	global.Logger.DebugContext(ctx, "Trading service call", attrs...)
+}
+
+// debugBody redacts sensitive values from a JSON body and truncates it.
+// Bodies that are not JSON are only truncated.
+func debugBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	text := string(body)
+	var doc interface{}
+	if json.Unmarshal(body, &doc) == nil {
+		if redacted, err := json.Marshal(redactSensitive(doc)); err == nil {
+			text = string(redacted)
+		}
+	}
+	if len(text) > maxDebugBodyBytes {
+		return text[:maxDebugBodyBytes] + "...(truncated)"
+	}
+	return text
+}
+
+func redactSensitive(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if isSensitiveKey(k) {
+				v[k] = "[REDACTED]"
+			} else {
+				v[k] = redactSensitive(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactSensitive(child)
+		}
+	}
+	return v
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, suffix := range sensitiveKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
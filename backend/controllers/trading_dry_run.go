@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/models"
+)
+
+const dryRunTaskIDPrefix = "dryrun-"
+
+// dryRunDelay is how long a dry run stays pending before completing (five
+// seconds by default), so clients exercise their polling.
+func dryRunDelay() time.Duration {
+	if d := config.AppConfig.Trading.DryRunDelay; d > 0 {
+		return d
+	}
+	return 5 * time.Second
+}
+
+func newDryRunTaskID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return dryRunTaskIDPrefix + hex.EncodeToString(b)
+}
+
+// completeDryRun finishes a dry-run task with a canned HOLD decision once
+// dryRunDelay has passed since it was created. Until then it stays pending.
+// The result depends only on the task, so repeated runs are identical.
+func completeDryRun(task *models.TradingAnalysisTask) {
+	delay := dryRunDelay()
+	completedAt := task.CreatedAt.Add(delay)
+	if time.Now().Before(completedAt) {
+		return
+	}
+
+	proposal := "HOLD: dry run for " + task.Ticker + " on " + task.AnalysisDate + "; no model was called."
+	applyServiceResponse(task, &PythonServiceResponse{
+		TaskID: task.TaskID,
+		Status: "completed",
+		Ticker: task.Ticker,
+		Date:   task.AnalysisDate,
+		Decision: map[string]interface{}{
+			"action":       "HOLD",
+			"confidence":   0.5,
+			"raw_decision": map[string]interface{}{"dry_run": true, "action": "HOLD"},
+		},
+		AnalysisReport: map[string]interface{}{
+			"dry_run":                true,
+			"final_trade_decision":   proposal,
+			"investment_plan":        proposal,
+			"trader_investment_plan": proposal,
+			"__key_outputs": map[string]interface{}{
+				"trader": map[string]interface{}{"transaction_proposal": "HOLD"},
+			},
+		},
+		StageTimes:            map[string]float64{"dry_run": delay.Seconds()},
+		CompletedAt:           completedAt.UTC().Format(time.RFC3339),
+		ProcessingTimeSeconds: delay.Seconds(),
+	})
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+)
+
+// exportFlushEvery is how many rows are written between flushes to the client.
+const exportFlushEvery = 100
+
+// AnalysisExportRow is one line of an analysis history export.
+type AnalysisExportRow struct {
+	TaskID                string    `json:"task_id"`
+	Ticker                string    `json:"ticker"`
+	AnalysisDate          string    `json:"analysis_date"`
+	Status                string    `json:"status"`
+	Action                *string   `json:"action"`
+	Confidence            *float64  `json:"confidence"`
+	ProcessingTimeSeconds float64   `json:"processing_time_seconds"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+var analysisExportHeader = []string{
+	"task_id", "ticker", "analysis_date", "status", "action", "confidence", "processing_time_seconds", "created_at",
+}
+
+func (r *AnalysisExportRow) csvRecord() []string {
+	record := []string{
+		r.TaskID,
+		r.Ticker,
+		r.AnalysisDate,
+		r.Status,
+		"",
+		"",
+		strconv.FormatFloat(r.ProcessingTimeSeconds, 'f', -1, 64),
+		r.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if r.Action != nil {
+		record[4] = *r.Action
+	}
+	if r.Confidence != nil {
+		record[5] = strconv.FormatFloat(*r.Confidence, 'f', -1, 64)
+	}
+	return record
+}
+
+// ExportAnalyses streams the current user's analysis history as CSV or JSON.
+// Rows are read from the database and written out one at a time, so the full
+// history is never held in memory. The format query parameter takes precedence
+// over the Accept header.
+// @Summary      Export the current user's analyses
+// @Tags         trading
+// @Produce      text/csv
+// @Produce      json
+// @Security     BearerAuth
+// @Param        format     query     string  false  "csv (default) or json"
+// @Param        date_from  query     string  false  "Earliest analysis date (YYYY-MM-DD)"
+// @Param        date_to    query     string  false  "Latest analysis date (YYYY-MM-DD)"
+// @Success      200        {array}   AnalysisExportRow
+// @Failure      400        {object}  map[string]string
+// @Failure      406        {object}  map[string]interface{}
+// @Router       /api/v1/trading/analyses/export [get]
+func ExportAnalyses(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = "csv"
+		if middlewares.NegotiatedType(c) == middlewares.MediaJSON {
+			format = "json"
+		}
+	}
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+		return
+	}
+
+	dates, ok := bindAnalysisDateRange(c)
+	if !ok {
+		return
+	}
+
+	rows, err := dates.apply(userAnalysesQuery(global.DB.WithContext(c.Request.Context()), userID)).
+		Select("trading_analysis_tasks.task_id, trading_analysis_tasks.ticker, trading_analysis_tasks.analysis_date, " +
+			"trading_analysis_tasks.status, trading_decisions.action, trading_decisions.confidence, " +
+			"trading_analysis_tasks.processing_time_seconds, trading_analysis_tasks.created_at").
+		Joins("LEFT JOIN trading_decisions ON trading_decisions.task_id = trading_analysis_tasks.task_id AND trading_decisions.deleted_at IS NULL").
+		Order("trading_analysis_tasks.created_at DESC").
+		Rows()
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("analyses-%s.%s", time.Now().UTC().Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	var (
+		writeRow func(*AnalysisExportRow) error
+		flush    func() error // pushes buffered rows to the client
+		finish   func() error // completes the document
+	)
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(c.Writer)
+		if err := w.Write(analysisExportHeader); err != nil {
+			return
+		}
+		writeRow = func(r *AnalysisExportRow) error { return w.Write(r.csvRecord()) }
+		flush = func() error {
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		}
+		finish = flush
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(c.Writer)
+		first := true
+		if _, err := c.Writer.WriteString("["); err != nil {
+			return
+		}
+		writeRow = func(r *AnalysisExportRow) error {
+			if !first {
+				if _, err := c.Writer.WriteString(","); err != nil {
+					return err
+				}
+			}
+			first = false
+			return enc.Encode(r)
+		}
+		flush = func() error {
+			c.Writer.Flush()
+			return nil
+		}
+		finish = func() error {
+			if _, err := c.Writer.WriteString("]\n"); err != nil {
+				return err
+			}
+			return flush()
+		}
+	}
+	c.Status(http.StatusOK)
+
+	count := 0
+	for rows.Next() {
+		var row AnalysisExportRow
+		if err := global.DB.ScanRows(rows, &row); err != nil {
+			global.Logger.Error("Failed to read analysis export row", "error", err)
+			return
+		}
+		if err := writeRow(&row); err != nil {
+			// Client went away
+			return
+		}
+		if count++; count%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		global.Logger.Error("Analysis export aborted", "error", err)
+		return
+	}
+	_ = finish()
+}
+
+// exportRowFromTask converts a loaded task, with its decision preloaded, to the
+// export row layout.
+func exportRowFromTask(task *models.TradingAnalysisTask) AnalysisExportRow {
+	row := AnalysisExportRow{
+		TaskID:                task.TaskID,
+		Ticker:                task.Ticker,
+		AnalysisDate:          task.AnalysisDate,
+		Status:                task.Status,
+		ProcessingTimeSeconds: task.ProcessingTimeSeconds,
+		CreatedAt:             task.CreatedAt,
+	}
+	if task.Decision != nil {
+		row.Action = &task.Decision.Action
+		row.Confidence = &task.Decision.Confidence
+	}
+	return row
+}
+
+// writeAnalysesCSV renders one page of tasks as CSV in the export layout. The
+// total is sent in X-Total-Count since CSV has nowhere to put it.
+func writeAnalysesCSV(c *gin.Context, tasks []models.TradingAnalysisTask, total int64) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(analysisExportHeader)
+	for i := range tasks {
+		row := exportRowFromTask(&tasks[i])
+		_ = w.Write(row.csvRecord())
+	}
+	w.Flush()
+}
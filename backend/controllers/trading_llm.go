@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/gin-gonic/gin"
+)
+
+// llmSettings are the LLM choices of an analysis after request fields,
+// llm_config and the configured defaults have been combined.
+type llmSettings struct {
+	Provider string
+	Model    string
+	BaseURL  string
+	// Config is the llm_config sent to the Python service, nil when empty
+	Config map[string]interface{}
+}
+
+// Models returns every model the analysis will run with.
+func (s llmSettings) Models() []string {
+	models := []string{s.Model}
+	for _, key := range []string{"quick_think_llm", "deep_think_llm"} {
+		if m, ok := s.Config[key].(string); ok && m != s.Model {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// resolveLLMSettings combines the request's LLM choices. Explicit fields win
+// over llm_config, which wins over the configured defaults.
+func resolveLLMSettings(req *AnalysisRequest) llmSettings {
+	getStr := func(key string) string {
+		if req.LLMConfig == nil {
+			return ""
+		}
+		if v, ok := req.LLMConfig[key]; ok {
+			if s, ok := v.(string); ok {
+				return s
+			}
+		}
+		return ""
+	}
+
+	tradingConf := config.AppConfig.Trading
+	s := llmSettings{
+		Provider: strings.ToLower(firstNonEmpty(req.LLMProvider, getStr("provider"), tradingConf.DefaultLLMProvider)),
+		Model:    firstNonEmpty(req.LLMModel, getStr("quick_think_llm"), getStr("deep_think_llm"), tradingConf.DefaultLLMModel),
+		BaseURL:  firstNonEmpty(req.LLMBaseURL, getStr("base_url")),
+	}
+
+	llmConfig := make(map[string]interface{}, len(req.LLMConfig)+4)
+	for k, v := range req.LLMConfig {
+		llmConfig[k] = v
+	}
+	if s.Provider != "" {
+		llmConfig["provider"] = s.Provider
+	}
+	if s.Model != "" {
+		if req.LLMModel != "" || getStr("quick_think_llm") == "" {
+			llmConfig["quick_think_llm"] = s.Model
+		}
+		if req.LLMModel != "" || getStr("deep_think_llm") == "" {
+			llmConfig["deep_think_llm"] = s.Model
+		}
+	}
+	if s.BaseURL != "" {
+		llmConfig["base_url"] = s.BaseURL
+	}
+	if len(llmConfig) > 0 {
+		s.Config = llmConfig
+	}
+	return s
+}
+
+// checkLLMChoice rejects providers the Python service does not support and,
+// when trading.allowedLLMs is set, providers or models it does not list.
+// Empty values are left to the service's defaults.
+func checkLLMChoice(provider string, models ...string) error {
+	if provider == "" {
+		return nil
+	}
+	if !knownLLMProviders[provider] {
+		return fmt.Errorf("unsupported llm_provider: %s", provider)
+	}
+
+	allowed := config.AppConfig.Trading.AllowedLLMs
+	if len(allowed) == 0 {
+		return nil
+	}
+	allowedModels, ok := allowed[provider]
+	if !ok {
+		return fmt.Errorf("llm_provider %s is not allowed on this server", provider)
+	}
+	for _, model := range models {
+		if model != "" && len(allowedModels) > 0 && !slices.Contains(allowedModels, model) {
+			return fmt.Errorf("llm_model %s is not allowed for %s", model, provider)
+		}
+	}
+	return nil
+}
+
+// checkLLMBaseURL rejects a user-supplied base URL that trading.allowedLLMBaseURLs
+// does not list, once it or trading.allowedLLMs restricts the LLM choices. The
+// service sends the server's provider keys to whatever URL it is given.
+func checkLLMBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+	tradingConf := config.AppConfig.Trading
+	if len(tradingConf.AllowedLLMs) == 0 && len(tradingConf.AllowedLLMBaseURLs) == 0 {
+		return nil
+	}
+	normalize := func(u string) string { return strings.TrimSuffix(strings.TrimSpace(u), "/") }
+	for _, allowed := range tradingConf.AllowedLLMBaseURLs {
+		if normalize(allowed) == normalize(baseURL) {
+			return nil
+		}
+	}
+	return fmt.Errorf("llm_base_url %s is not allowed on this server", baseURL)
+}
+
+// LLMProviderOption is a provider users may choose. An empty Models list means
+// any of the provider's models is accepted.
+type LLMProviderOption struct {
+	Provider string   `json:"provider"`
+	Models   []string `json:"models"`
+}
+
+// allowedLLMProviders lists the selectable providers, sorted by name: those in
+// trading.allowedLLMs, or every known provider when it is empty.
+func allowedLLMProviders() []LLMProviderOption {
+	options := []LLMProviderOption{}
+	if allowed := config.AppConfig.Trading.AllowedLLMs; len(allowed) > 0 {
+		for provider, models := range allowed {
+			if knownLLMProviders[provider] {
+				options = append(options, LLMProviderOption{Provider: provider, Models: append([]string{}, models...)})
+			}
+		}
+	} else {
+		for provider := range knownLLMProviders {
+			options = append(options, LLMProviderOption{Provider: provider, Models: []string{}})
+		}
+	}
+	sort.Slice(options, func(i, j int) bool { return options[i].Provider < options[j].Provider })
+	return options
+}
+
+// ListLLMProviders returns the LLM providers and models analyses may use, for
+// clients to offer as choices
+// @Summary      List selectable LLM providers and models
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Router       /api/v1/trading/providers [get]
+func ListLLMProviders(c *gin.Context) {
+	tradingConf := config.AppConfig.Trading
+	c.JSON(http.StatusOK, gin.H{
+		"providers":        allowedLLMProviders(),
+		"default_provider": strings.ToLower(tradingConf.DefaultLLMProvider),
+		"default_model":    tradingConf.DefaultLLMModel,
+	})
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AnalysisQuota is a user's analysis allowance for the current UTC day. Limit
+// 0 means unlimited.
+type AnalysisQuota struct {
+	Limit     int       `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// dailyAnalysisLimit returns the daily quota for role: the role's override if
+// configured, otherwise trading.dailyQuota. 0 means unlimited.
+func dailyAnalysisLimit(role string) int {
+	conf := config.AppConfig.Trading
+	if limit, ok := conf.RoleQuotas[role]; ok {
+		return limit
+	}
+	return conf.DailyQuota
+}
+
+// analysisQuota counts the analyses the user has requested since midnight UTC.
+// Deleted analyses still count, so deleting history does not reset the quota;
+// dry runs cost nothing and do not.
+func analysisQuota(userID interface{}, role string) (*AnalysisQuota, error) {
+	now := time.Now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	quota := &AnalysisQuota{
+		Limit:    dailyAnalysisLimit(role),
+		ResetsAt: midnight.AddDate(0, 0, 1),
+	}
+
+	if err := global.DB.Unscoped().Model(&models.TradingAnalysisTask{}).
+		Where("user_id = ? AND created_at >= ? AND NOT dry_run", userID, midnight).
+		Count(&quota.Used).Error; err != nil {
+		return nil, err
+	}
+	if quota.Limit > 0 {
+		quota.Remaining = max(int64(quota.Limit)-quota.Used, 0)
+	}
+	return quota, nil
+}
+
+// quotaCost is the number of analyses in reqs that count against the quota.
+func quotaCost(reqs ...AnalysisRequest) int {
+	n := 0
+	for _, req := range reqs {
+		if !req.DryRun {
+			n++
+		}
+	}
+	return n
+}
+
+// enforceAnalysisQuota checks that the user may request n more analyses today.
+// Otherwise it writes a 429 with the quota and its reset time and returns false.
+func enforceAnalysisQuota(c *gin.Context, n int) bool {
+	quota, err := analysisQuota(c.MustGet("user_id"), c.GetString("role"))
+	if err != nil {
+		respondDBError(c, err)
+		return false
+	}
+	if quota.Limit == 0 || quota.Remaining >= int64(n) {
+		return true
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(time.Until(quota.ResetsAt).Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": "daily analysis quota exceeded",
+		"quota": quota,
+	})
+	return false
+}
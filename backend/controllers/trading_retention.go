@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"gorm.io/gorm"
+)
+
+// pruneBatchSize bounds the tasks removed per transaction, so pruning a large
+// backlog does not hold locks on the tasks table for long.
+const pruneBatchSize = 500
+
+// pruneCandidatesSQL selects finished tasks created before the cutoff that are
+// not among their user's newest keepPerUser live tasks, plus, when purging,
+// soft-deleted tasks created before the cutoff.
+const pruneCandidatesSQL = `SELECT id, task_id FROM (
+	SELECT id, task_id, status, created_at, deleted_at,
+		ROW_NUMBER() OVER (PARTITION BY user_id, deleted_at IS NULL ORDER BY created_at DESC, id DESC) AS rn
+	FROM trading_analysis_tasks
+) t
+WHERE created_at < @cutoff
+	AND ((deleted_at IS NULL AND rn > @keep AND status IN ('completed', 'failed'))
+		OR (@purge AND deleted_at IS NOT NULL))
+ORDER BY id
+LIMIT @limit`
+
+// PruneAnalysisTasks removes completed and failed tasks created before cutoff,
+// together with their decisions, keeping each user's newest keepPerUser tasks
+// whatever their age. Rows are soft-deleted unless hard is set, in which case
+// they are deleted outright along with tasks users had already deleted. It
+// returns the number of tasks and decisions removed.
+func PruneAnalysisTasks(ctx context.Context, cutoff time.Time, keepPerUser int, hard bool) (tasks, decisions int64, err error) {
+	if keepPerUser < 0 {
+		keepPerUser = 0
+	}
+	for ctx.Err() == nil {
+		var batch []struct {
+			ID     uint
+			TaskID string
+		}
+		if err := global.DB.WithContext(ctx).Raw(pruneCandidatesSQL, map[string]interface{}{
+			"cutoff": cutoff,
+			"keep":   keepPerUser,
+			"purge":  hard,
+			"limit":  pruneBatchSize,
+		}).Scan(&batch).Error; err != nil {
+			return tasks, decisions, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		ids := make([]uint, len(batch))
+		taskIDs := make([]string, len(batch))
+		for i, row := range batch {
+			ids[i], taskIDs[i] = row.ID, row.TaskID
+		}
+		err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if hard {
+				tx = tx.Unscoped()
+			}
+			res := tx.Where("task_id IN ?", taskIDs).Delete(&models.TradingDecision{})
+			if res.Error != nil {
+				return res.Error
+			}
+			decisions += res.RowsAffected
+			res = tx.Where("id IN ?", ids).Delete(&models.TradingAnalysisTask{})
+			if res.Error != nil {
+				return res.Error
+			}
+			tasks += res.RowsAffected
+			return nil
+		})
+		if err != nil {
+			return tasks, decisions, err
+		}
+		if len(batch) < pruneBatchSize {
+			break
+		}
+	}
+	return tasks, decisions, ctx.Err()
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RetryAnalysis re-runs a failed analysis as a new task linked to it through
+// retry_of. The ticker, date, stored config, LLM provider, model and base URL
+// are reused; other llm_config settings are not stored with a task, so the
+// service defaults apply to them. A task can be retried once; retry the new
+// task if it fails too.
+// @Summary      Retry a failed analysis
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        task_id  path      string  true  "Task ID of the failed analysis"
+// @Success      202      {object}  models.TradingAnalysisTask
+// @Failure      404      {object}  map[string]string
+// @Failure      409      {object}  map[string]string
+// @Failure      429      {object}  map[string]interface{}
+// @Failure      502      {object}  map[string]string
+// @Router       /api/v1/trading/analysis/{task_id}/retry [post]
+func RetryAnalysis(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var task models.TradingAnalysisTask
+	if err := global.DB.Where("task_id = ? AND user_id = ?", c.Param("task_id"), userID).
+		First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+	if task.Status != "failed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "only failed analyses can be retried, this one is " + task.Status})
+		return
+	}
+
+	var retry models.TradingAnalysisTask
+	err := global.DB.Where("retry_of = ? AND user_id = ?", task.TaskID, userID).First(&retry).Error
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "analysis was already retried as " + retry.TaskID})
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		respondDBError(c, err)
+		return
+	}
+
+	// Claim the retry before submitting, so that concurrent retries of the
+	// same task can't both go through and both count against the quota
+	claim := global.DB.Model(&models.TradingAnalysisTask{}).
+		Where("id = ? AND retry_claimed = ?", task.ID, false).
+		Update("retry_claimed", true)
+	if claim.Error != nil {
+		respondDBError(c, claim.Error)
+		return
+	}
+	if claim.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "analysis is already being retried"})
+		return
+	}
+	// Lets the client try again when the retry was not submitted
+	release := func() {
+		if err := global.DB.Model(&models.TradingAnalysisTask{}).
+			Where("id = ?", task.ID).
+			Update("retry_claimed", false).Error; err != nil {
+			global.Logger.Error("Failed to release retry claim", "task_id", task.TaskID, "error", err)
+		}
+	}
+
+	req := AnalysisRequest{
+		Ticker:      task.Ticker,
+		Date:        task.AnalysisDate,
+		LLMProvider: task.LLMProvider,
+		LLMModel:    task.LLMModel,
+		LLMBaseURL:  task.LLMBaseURL,
+		CallbackURL: task.CallbackURL,
+		DryRun:      task.DryRun,
+		ScheduleID:  task.ScheduleID,
+		RetryOf:     task.TaskID,
+	}
+	// A preset's settings were merged into the stored config when the task was
+	// created, so the config is reused rather than the preset's current state
+	if task.Config != nil {
+		if err := json.Unmarshal([]byte(*task.Config), &req.Config); err != nil {
+			release()
+			global.Logger.Error("Stored analysis config is invalid", "task_id", task.TaskID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "stored config is invalid"})
+			return
+		}
+	}
+
+	if !enforceAnalysisQuota(c, quotaCost(req)) {
+		release()
+		return
+	}
+	retried, existing, aerr := submitAnalysis(c.Request.Context(), userID.(uint), req)
+	if aerr != nil {
+		release()
+		c.JSON(aerr.status, gin.H{"error": aerr.message})
+		return
+	}
+	c.JSON(submittedStatus(existing), retried)
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTopTickers = 10
+	maxTopTickers     = 50
+)
+
+// TickerStats is the per-ticker breakdown in the global statistics.
+type TickerStats struct {
+	Ticker    string `json:"ticker"`
+	Analyses  int64  `json:"analyses"`
+	Completed int64  `json:"completed"`
+	Failed    int64  `json:"failed"`
+}
+
+// GetGlobalAnalysisStats aggregates analysis statistics across all users. All
+// numbers are computed in SQL; no task rows are loaded.
+// @Summary      Get analysis statistics for all users
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        top  query     int  false  "Number of most-analyzed tickers to include (default 10, max 50)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/trading/stats/global [get]
+func GetGlobalAnalysisStats(c *gin.Context) {
+	top, err := strconv.Atoi(c.Query("top"))
+	if err != nil || top < 1 {
+		top = defaultTopTickers
+	}
+	if top > maxTopTickers {
+		top = maxTopTickers
+	}
+
+	var totals struct {
+		Total                int64
+		Completed            int64
+		Failed               int64
+		Users                int64
+		AvgProcessingSeconds *float64
+		MaxProcessingSeconds *float64
+	}
+	if err := global.DB.Model(&models.TradingAnalysisTask{}).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+			COUNT(*) FILTER (WHERE status = 'failed') AS failed,
+			COUNT(DISTINCT user_id) AS users,
+			AVG(processing_time_seconds) FILTER (WHERE status = 'completed') AS avg_processing_seconds,
+			MAX(processing_time_seconds) FILTER (WHERE status = 'completed') AS max_processing_seconds`).
+		Scan(&totals).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	var actionRows []struct {
+		Action string
+		Count  int64
+	}
+	if err := global.DB.Model(&models.TradingDecision{}).
+		Joins("JOIN trading_analysis_tasks ON trading_analysis_tasks.task_id = trading_decisions.task_id AND trading_analysis_tasks.deleted_at IS NULL").
+		Select("trading_decisions.action, COUNT(*) AS count").
+		Group("trading_decisions.action").
+		Scan(&actionRows).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+	actions := map[string]int64{}
+	for _, row := range actionRows {
+		actions[row.Action] = row.Count
+	}
+
+	tickers := []TickerStats{}
+	if err := global.DB.Model(&models.TradingAnalysisTask{}).
+		Select(`UPPER(ticker) AS ticker,
+			COUNT(*) AS analyses,
+			COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+			COUNT(*) FILTER (WHERE status = 'failed') AS failed`).
+		Group("UPPER(ticker)").
+		Order("analyses DESC, ticker").
+		Limit(top).
+		Scan(&tickers).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	var completionRate, failureRate float64
+	if totals.Total > 0 {
+		completionRate = float64(totals.Completed) / float64(totals.Total)
+		failureRate = float64(totals.Failed) / float64(totals.Total)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_analyses":              totals.Total,
+		"completed":                   totals.Completed,
+		"failed":                      totals.Failed,
+		"pending":                     totals.Total - totals.Completed - totals.Failed,
+		"users":                       totals.Users,
+		"completion_rate":             completionRate,
+		"failure_rate":                failureRate,
+		"avg_processing_time_seconds": totals.AvgProcessingSeconds,
+		"max_processing_time_seconds": totals.MaxProcessingSeconds,
+		"decisions":                   actions,
+		"top_tickers":                 tickers,
+	})
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// analysisStats holds the numbers GetAnalysisStats reports.
+type analysisStats struct {
+	Total     int64 `json:"total_analyses"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+	Pending   int64 `json:"pending"`
+	Decisions struct {
+		Buy  int64 `json:"buy"`
+		Sell int64 `json:"sell"`
+		Hold int64 `json:"hold"`
+	} `json:"decisions"`
+	AvgProcessingSeconds *float64 `json:"avg_processing_time_seconds"`
+	MaxProcessingSeconds *float64 `json:"max_processing_time_seconds"`
+	AvgConfidence        *float64 `json:"avg_confidence"`
+}
+
+// oldAnalysisStats computes the statistics with the separate queries
+// GetAnalysisStats used to run.
+func oldAnalysisStats(t *testing.T, db *gorm.DB, userID uint) analysisStats {
+	t.Helper()
+	var s analysisStats
+	tasks := func() *gorm.DB { return db.Model(&models.TradingAnalysisTask{}) }
+	tasks().Where("user_id = ?", userID).Count(&s.Total)
+	tasks().Where("user_id = ? AND status = ?", userID, "completed").Count(&s.Completed)
+	tasks().Where("user_id = ? AND status = ?", userID, "failed").Count(&s.Failed)
+	s.Pending = s.Total - s.Completed - s.Failed
+
+	decisions := func(action string) int64 {
+		var n int64
+		db.Model(&models.TradingDecision{}).
+			Joins("JOIN trading_analysis_tasks ON trading_decisions.task_id = trading_analysis_tasks.task_id").
+			Where("trading_analysis_tasks.user_id = ? AND trading_decisions.action = ?", userID, action).
+			Count(&n)
+		return n
+	}
+	s.Decisions.Buy, s.Decisions.Sell, s.Decisions.Hold = decisions("BUY"), decisions("SELL"), decisions("HOLD")
+
+	var timing struct {
+		AvgProcessingSeconds *float64
+		MaxProcessingSeconds *float64
+	}
+	tasks().Where("user_id = ? AND status = ?", userID, "completed").
+		Select("AVG(processing_time_seconds) AS avg_processing_seconds, MAX(processing_time_seconds) AS max_processing_seconds").
+		Scan(&timing)
+	s.AvgProcessingSeconds, s.MaxProcessingSeconds = timing.AvgProcessingSeconds, timing.MaxProcessingSeconds
+
+	var confidence struct{ AvgConfidence *float64 }
+	db.Model(&models.TradingDecision{}).
+		Joins("JOIN trading_analysis_tasks ON trading_decisions.task_id = trading_analysis_tasks.task_id").
+		Where("trading_analysis_tasks.user_id = ?", userID).
+		Select("AVG(trading_decisions.confidence) AS avg_confidence").
+		Scan(&confidence)
+	s.AvgConfidence = confidence.AvgConfidence
+	return s
+}
+
+func TestGetAnalysisStatsMatchesSeparateQueries(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	seed := []struct {
+		userID     uint
+		taskID     string
+		status     string
+		seconds    float64
+		action     string
+		confidence float64
+	}{
+		{1, "t1", "completed", 10, "BUY", 0.8},
+		{1, "t2", "completed", 20, "SELL", 0.6},
+		{1, "t3", "completed", 45, "BUY", 0.7},
+		{1, "t4", "failed", 3, "", 0},
+		{1, "t5", "pending", 0, "", 0},
+		{1, "t6", "processing", 0, "", 0},
+		{2, "t7", "completed", 99, "HOLD", 0.9},
+	}
+	for _, s := range seed {
+		task := models.TradingAnalysisTask{UserID: s.userID, TaskID: s.taskID, Ticker: "AAPL",
+			AnalysisDate: "2024-01-02", Status: s.status, ProcessingTimeSeconds: s.seconds}
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+		if s.action != "" {
+			if err := db.Create(&models.TradingDecision{TaskID: s.taskID, Action: s.action, Confidence: s.confidence}).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, userID := range []uint{1, 2, 3} {
+		r := gin.New()
+		r.GET("/stats", asUser(userID), GetAnalysisStats)
+		w := testutil.Do(r, http.MethodGet, "/stats", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("user %d: status %d: %s", userID, w.Code, w.Body)
+		}
+		var got analysisStats
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if userID == 1 && (got.Total != 6 || got.Pending != 2 || got.Decisions.Buy != 2 || *got.MaxProcessingSeconds != 45) {
+			t.Fatalf("user 1: stats %+v don't reflect the seeded tasks", got)
+		}
+
+		want := oldAnalysisStats(t, db, userID)
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("user %d: stats %s, want %s", userID, gotJSON, wantJSON)
+		}
+	}
+}
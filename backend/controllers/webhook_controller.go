@@ -0,0 +1,480 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	webhookSignatureHeader = "X-FinGOAT-Signature"
+	webhookEventHeader     = "X-FinGOAT-Event"
+)
+
+// WebhookPayload is the body POSTed to a callback URL when an analysis finishes.
+type WebhookPayload struct {
+	Event                 string                  `json:"event"`
+	TaskID                string                  `json:"task_id"`
+	Ticker                string                  `json:"ticker"`
+	AnalysisDate          string                  `json:"analysis_date"`
+	Status                string                  `json:"status"`
+	Error                 string                  `json:"error,omitempty"`
+	CompletedAt           *time.Time              `json:"completed_at,omitempty"`
+	ProcessingTimeSeconds float64                 `json:"processing_time_seconds,omitempty"`
+	Decision              *WebhookDecisionSummary `json:"decision,omitempty"`
+}
+
+type WebhookDecisionSummary struct {
+	Action       string  `json:"action"`
+	Confidence   float64 `json:"confidence"`
+	PositionSize int     `json:"position_size,omitempty"`
+}
+
+type WebhookInput struct {
+	URL          string `json:"url" binding:"omitempty,http_url"`
+	RotateSecret bool   `json:"rotate_secret"`
+}
+
+// GetWebhook returns the user's webhook URL and signing secret
+// @Summary      Get the webhook configuration
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/trading/webhook [get]
+func GetWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var sub models.WebhookSubscription
+	if err := global.DB.Where("user_id = ?", userID).First(&sub).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not configured"})
+		} else {
+			respondDBError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": sub.URL, "secret": sub.Secret})
+}
+
+// UpdateWebhook sets the user's default callback URL, optionally rotating the secret
+// @Summary      Set the webhook URL
+// @Tags         trading
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      WebhookInput  true  "Webhook settings"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Router       /api/v1/trading/webhook [put]
+func UpdateWebhook(c *gin.Context) {
+	var input WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	sub, err := ensureWebhookSubscription(userID.(uint))
+	if err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	sub.URL = input.URL
+	if input.RotateSecret {
+		if sub.Secret, err = newWebhookSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := global.DB.Save(sub).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": sub.URL, "secret": sub.Secret})
+}
+
+// DeleteWebhook removes the user's webhook configuration
+// @Summary      Remove the webhook configuration
+// @Tags         trading
+// @Security     BearerAuth
+// @Success      204
+// @Router       /api/v1/trading/webhook [delete]
+func DeleteWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := global.DB.Unscoped().Where("user_id = ?", userID).Delete(&models.WebhookSubscription{}).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// WebhookDelivery is an outbox event as shown to its owner.
+type WebhookDelivery struct {
+	models.OutboxEvent
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ListWebhookDeliveries returns the user's webhook deliveries, by default the
+// dead-lettered ones
+// @Summary      List webhook deliveries
+// @Tags         trading
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status     query     string  false  "pending, delivered or failed (default failed)"
+// @Param        page       query     int     false  "Page number"
+// @Param        page_size  query     int     false  "Page size"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]string
+// @Router       /api/v1/trading/webhook/deliveries [get]
+func ListWebhookDeliveries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	status := c.DefaultQuery("status", outboxFailed)
+	if status != outboxPending && status != outboxDelivered && status != outboxFailed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, delivered or failed"})
+		return
+	}
+
+	offset, limit := pagination.Parse(c)
+	query := global.DB.Model(&models.OutboxEvent{}).Where("user_id = ? AND status = ?", userID, status)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	var events []models.OutboxEvent
+	if err := query.Session(&gorm.Session{}).
+		Order("updated_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		respondDBError(c, err)
+		return
+	}
+
+	deliveries := make([]WebhookDelivery, len(events))
+	for i, event := range events {
+		deliveries[i] = WebhookDelivery{OutboxEvent: event, Payload: json.RawMessage(event.Payload)}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"pagination": pagination.NewMeta(offset, limit, total),
+	})
+}
+
+// ensureWebhookSubscription returns the user's subscription, creating one with a
+// fresh secret if none exists.
+func ensureWebhookSubscription(userID uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := global.DB.Where("user_id = ?", userID).First(&sub).Error
+	if err == nil {
+		return &sub, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	sub = models.WebhookSubscription{UserID: userID, Secret: secret}
+	if err := global.DB.Create(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+const (
+	outboxPending   = "pending"
+	outboxDelivered = "delivered"
+	outboxFailed    = "failed"
+
+	// outboxBatchSize bounds the events handled per dispatch cycle.
+	outboxBatchSize = 50
+	// outboxLease keeps a claimed event from being picked up by another
+	// dispatcher while it is being sent.
+	outboxLease = 2 * time.Minute
+	// Retries back off exponentially from outboxInitialBackoff up to outboxMaxBackoff.
+	outboxInitialBackoff = 10 * time.Second
+	outboxMaxBackoff     = time.Hour
+)
+
+// enqueueTaskWebhook writes the completion webhook for a finished task to the
+// outbox, using tx so that it commits together with the task. Only the first
+// call for a task enqueues anything, and nothing is enqueued when neither the
+// request nor the user's subscription has a callback URL.
+func enqueueTaskWebhook(tx *gorm.DB, task *models.TradingAnalysisTask) error {
+	url := task.CallbackURL
+	if url == "" {
+		var sub models.WebhookSubscription
+		err := tx.Where("user_id = ?", task.UserID).First(&sub).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		url = sub.URL
+	}
+	if url == "" {
+		return nil
+	}
+
+	// Claim the task's webhook; concurrent writers of the same completion lose
+	res := tx.Model(&models.TradingAnalysisTask{}).
+		Where("id = ? AND COALESCE(webhook_status, '') = ''", task.ID).
+		Update("webhook_status", outboxPending)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	task.WebhookStatus = outboxPending
+
+	payload := newWebhookPayload(task)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxEvent{
+		UserID:        task.UserID,
+		TaskID:        task.TaskID,
+		Event:         payload.Event,
+		URL:           url,
+		Payload:       string(body),
+		Status:        outboxPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// DeliverPendingWebhooks sends due outbox events. A failed delivery is retried
+// with exponential backoff; after webhook.maxAttempts attempts the event is
+// marked failed (dead-lettered) and stays visible to the user.
+func DeliverPendingWebhooks(ctx context.Context) {
+	var events []models.OutboxEvent
+	if err := global.DB.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", outboxPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(outboxBatchSize).
+		Find(&events).Error; err != nil {
+		global.Logger.Warn("Failed to load pending webhooks", "error", err)
+		return
+	}
+
+	for i := range events {
+		if ctx.Err() != nil {
+			return
+		}
+		event := &events[i]
+		// Claim the event by pushing its next attempt past the lease; another
+		// dispatcher that loaded it too will find next_attempt_at changed
+		res := global.DB.WithContext(ctx).Model(&models.OutboxEvent{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", event.ID, outboxPending, event.NextAttemptAt).
+			Update("next_attempt_at", time.Now().Add(outboxLease))
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
+		}
+		deliverOutboxEvent(ctx, event)
+	}
+}
+
+func deliverOutboxEvent(ctx context.Context, event *models.OutboxEvent) {
+	maxAttempts := config.AppConfig.Webhook.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	timeout := config.AppConfig.Webhook.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	var sub models.WebhookSubscription
+	err := global.DB.Where("user_id = ?", event.UserID).First(&sub).Error
+	if err == nil {
+		client := &http.Client{Transport: webhookTransport, Timeout: timeout}
+		err = postWebhook(ctx, client, event.URL, sub.Secret, event.Event, []byte(event.Payload))
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The user removed their webhook; nothing can sign the delivery, so
+		// dead-letter it now instead of retrying
+		err = errors.New("webhook subscription was removed")
+		event.Attempts = maxAttempts - 1
+	}
+	if ctx.Err() != nil {
+		// Shutting down; the lease expires and the event is retried after restart
+		return
+	}
+
+	event.Attempts++
+	updates := map[string]interface{}{"attempts": event.Attempts}
+	taskStatus := ""
+	switch {
+	case err == nil:
+		now := time.Now().UTC()
+		updates["status"] = outboxDelivered
+		updates["delivered_at"] = &now
+		updates["last_error"] = ""
+		taskStatus = outboxDelivered
+	case event.Attempts >= maxAttempts:
+		updates["status"] = outboxFailed
+		updates["last_error"] = err.Error()
+		taskStatus = outboxFailed
+		global.Logger.Error("Webhook delivery dead-lettered",
+			"event_id", event.ID,
+			"task_id", event.TaskID,
+			"user_id", event.UserID,
+			"url", event.URL,
+			"attempts", event.Attempts,
+			"error", err,
+		)
+	default:
+		backoff := min(outboxInitialBackoff<<(event.Attempts-1), outboxMaxBackoff)
+		updates["next_attempt_at"] = time.Now().Add(backoff)
+		updates["last_error"] = err.Error()
+		global.Logger.Warn("Webhook delivery failed; will retry",
+			"event_id", event.ID, "task_id", event.TaskID, "attempt", event.Attempts, "retry_in", backoff.String(), "error", err)
+	}
+
+	if err := global.DB.Model(event).Updates(updates).Error; err != nil {
+		global.Logger.Error("Failed to record webhook delivery", "event_id", event.ID, "error", err)
+		return
+	}
+	if taskStatus != "" {
+		global.DB.Model(&models.TradingAnalysisTask{}).Where("task_id = ?", event.TaskID).Update("webhook_status", taskStatus)
+	}
+}
+
+func newWebhookPayload(task *models.TradingAnalysisTask) WebhookPayload {
+	payload := WebhookPayload{
+		Event:                 "analysis." + task.Status,
+		TaskID:                task.TaskID,
+		Ticker:                task.Ticker,
+		AnalysisDate:          task.AnalysisDate,
+		Status:                task.Status,
+		Error:                 task.Error,
+		CompletedAt:           task.CompletedAt,
+		ProcessingTimeSeconds: task.ProcessingTimeSeconds,
+	}
+	if task.Decision != nil {
+		payload.Decision = &WebhookDecisionSummary{
+			Action:       task.Decision.Action,
+			Confidence:   task.Decision.Confidence,
+			PositionSize: task.Decision.PositionSize,
+		}
+	}
+	return payload
+}
+
+// webhookTransport carries webhook deliveries. Receivers are chosen by users,
+// so it refuses to connect to addresses inside the deployment. The check runs
+// on the address actually dialed, after DNS resolution, so a hostname that
+// resolves (or later rebinds) to such an address is refused too. Proxies are
+// not used, as the check would then only see the proxy.
+var webhookTransport = &http.Transport{
+	DialContext: (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   refuseNonPublicAddress,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// refuseNonPublicAddress is a net.Dialer Control hook that fails the dial when
+// address is loopback, private, link-local, unspecified or multicast.
+func refuseNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("webhook receiver address %q is not an IP address", host)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook receiver address %s is not public", ip)
+	}
+	return nil
+}
+
+// postWebhook sends one signed delivery. The signature is the hex HMAC-SHA256
+// of the raw body keyed with the user's secret.
+func postWebhook(ctx context.Context, client *http.Client, url, secret, event string, body []byte) error {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookSignatureHeader, signature)
+	req.Header.Set(webhookEventHeader, event)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver returned status %d", resp.StatusCode)
+	}
+	return nil
+}
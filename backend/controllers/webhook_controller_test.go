@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateWebhookRequiresHTTPURL(t *testing.T) {
+	testutil.Config(t)
+	testutil.DB(t)
+
+	r := gin.New()
+	r.PUT("/webhook", asUser(1), UpdateWebhook)
+	for _, url := range []string{"file:///etc/passwd", "gopher://example.com/", "ftp://example.com/hook"} {
+		w := testutil.Do(r, http.MethodPut, "/webhook", `{"url": "`+url+`"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("url %s: status %d, want 400", url, w.Code)
+		}
+	}
+	if w := testutil.Do(r, http.MethodPut, "/webhook", `{"url": "https://example.com/hook"}`); w.Code != http.StatusOK {
+		t.Fatalf("https url: status %d, want 200: %s", w.Code, w.Body)
+	}
+}
+
+func TestPostWebhookRefusesLoopbackReceiver(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: webhookTransport}
+	err := postWebhook(context.Background(), client, srv.URL, "secret", "analysis.completed", []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "not public") {
+		t.Fatalf("err = %v, want the loopback receiver refused", err)
+	}
+	if called {
+		t.Fatal("loopback receiver was reached")
+	}
+}
@@ -0,0 +1,43 @@
+// Package db maps database errors to HTTP responses.
+package db
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Postgres SQLSTATE codes handled by StatusForError.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+// StatusForError maps a database error to an HTTP status and a message that is
+// safe to return to clients: missing rows are 404, constraint violations 409,
+// and anything else a generic 500 that does not expose the SQL error.
+func StatusForError(err error) (int, string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound, "record not found"
+	case IsUniqueViolation(err):
+		return http.StatusConflict, "record already exists"
+	case isPgError(err, pgForeignKeyViolation):
+		return http.StatusConflict, "referenced record is missing or still in use"
+	default:
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
+
+// IsUniqueViolation reports whether err is a Postgres unique constraint
+// violation, or one GORM translated to ErrDuplicatedKey.
+func IsUniqueViolation(err error) bool {
+	return isPgError(err, pgUniqueViolation) || errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+func isPgError(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
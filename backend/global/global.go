@@ -1,6 +1,9 @@
 package global
 
 import (
+	"log/slog"
+	"os"
+
 	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
 )
@@ -8,4 +11,6 @@ import (
 var (
 	DB      *gorm.DB
 	RedisDB *redis.Client
+	// Logger is replaced with the configured logger by config.InitConfig.
+	Logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
 )
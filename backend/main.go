@@ -2,22 +2,62 @@ package main
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"syscall"
 	"time"
+	_ "time/tzdata" // the scheduler's time zone must resolve without system tzdata
 
 	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/controllers"
+	"github.com/JerryLinyx/FinGOAT/global"
 	"github.com/JerryLinyx/FinGOAT/router"
+	"github.com/JerryLinyx/FinGOAT/validators"
+	"github.com/JerryLinyx/FinGOAT/workers"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+//go:generate swag init -g main.go -o docs --parseDependency --parseDepth 1
+
+// @title                       FinGOAT API
+// @version                     1.0
+// @description                 Financial news, exchange rates and LLM-backed trading analysis.
+// @BasePath                    /
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 JWT as "Bearer <token>", obtained from /api/auth/login. Scripts may send an API key in X-API-Key instead.
 func main() {
 	config.InitConfig()
 
+	if err := validators.Register(); err != nil {
+		global.Logger.Error("Failed to register validators", "error", err)
+		os.Exit(1)
+	}
+
 	// Run database migrations
 	config.MigrateDB()
 
+	// Background workers share a root context that is cancelled on shutdown
+	rootCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+	var wg sync.WaitGroup
+	startWorker := func(run func(context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(rootCtx)
+		}()
+	}
+	startWorker(workers.RunFXFetcher)
+	startWorker(workers.RunTaskReconciler)
+	startWorker(workers.RunAnalysisScheduler)
+	startWorker(workers.RunOutboxDispatcher)
+	startWorker(workers.RunRateBroadcaster)
+	startWorker(workers.RunTaskPruner)
+
 	r := router.InitRouter()
 	port := config.AppConfig.App.Port
 	if port == "" {
@@ -28,21 +68,74 @@ func main() {
 		Handler: r,
 	}
 
+	appConf := config.AppConfig.App
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
+		var err error
+		if appConf.CertFile != "" {
+			global.Logger.Info("Serving HTTPS", "addr", port)
+			err = srv.ListenAndServeTLS(appConf.CertFile, appConf.KeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			global.Logger.Error("listen", "error", err)
+			os.Exit(1)
 		}
 	}()
 
+	var metricsSrv *http.Server
+	if metricsConf := config.AppConfig.Metrics; metricsConf.Enabled && metricsConf.Port != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		metricsSrv = &http.Server{
+			Addr:    metricsConf.Port,
+			Handler: mux,
+		}
+		go func() {
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				global.Logger.Error("metrics listen", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutdown Server ...")
+	global.Logger.Info("Shutdown Server ...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	timeout := config.AppConfig.App.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+
+	// Stop accepting requests and let in-flight ones finish
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server Shutdown:", err)
+		global.Logger.Error("Server Shutdown", "error", err)
 	}
-	log.Println("Server exiting")
+	// Shutdown does not wait for hijacked connections, so close websockets explicitly
+	controllers.CloseRateSubscribers()
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			global.Logger.Warn("Metrics Server Shutdown", "error", err)
+		}
+	}
+
+	// Then stop the workers and wait for their current cycle to complete
+	cancelWorkers()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		global.Logger.Warn("Timed out waiting for background workers")
+	}
+
+	config.CloseConnections()
+	global.Logger.Info("Server exiting")
 }
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	HTTPRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fingoat_http_requests_total",
+			Help: "Total number of HTTP requests by method, route and status.",
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "fingoat_http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	HTTPRequestsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fingoat_http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served by route.",
+		},
+		[]string{"route"},
+	)
+
+	TradingDecisionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fingoat_trading_decisions_total",
+			Help: "Completed trading analyses by resulting action (BUY/SELL/HOLD).",
+		},
+		[]string{"action"},
+	)
+
+	TradingCallsInFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fingoat_trading_calls_in_flight",
+			Help: "Calls to the trading service currently in flight.",
+		},
+	)
+
+	TradingCallsWaiting = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fingoat_trading_calls_waiting",
+			Help: "Calls to the trading service waiting for a free concurrency slot.",
+		},
+	)
+
+	TradingCallsLimit = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fingoat_trading_calls_limit",
+			Help: "Maximum number of concurrent calls to the trading service.",
+		},
+	)
+
+	SlowRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fingoat_slow_requests_total",
+			Help: "Requests slower than log.slowRequest, by method and route.",
+		},
+		[]string{"method", "route"},
+	)
+
+	SlowQueriesTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "fingoat_slow_queries_total",
+			Help: "Database queries slower than log.slowQuery.",
+		},
+	)
+
+	// TradingCircuitBreakerState reports the trading service circuit breaker:
+	// 0 = closed, 1 = half-open, 2 = open.
+	TradingCircuitBreakerState = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fingoat_trading_circuit_breaker_state",
+			Help: "Trading service circuit breaker state (0=closed, 1=half-open, 2=open).",
+		},
+	)
+)
+
+// Circuit breaker states as reported by TradingCircuitBreakerState.
+const (
+	CircuitClosed   = 0
+	CircuitHalfOpen = 1
+	CircuitOpen     = 2
+)
+
+func init() {
+	prometheus.MustRegister(
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		TradingDecisionsTotal,
+		TradingCircuitBreakerState,
+		TradingCallsInFlight,
+		TradingCallsWaiting,
+		TradingCallsLimit,
+		SlowRequestsTotal,
+		SlowQueriesTotal,
+	)
+}
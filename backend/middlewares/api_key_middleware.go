@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries an API key, as an alternative to a bearer token.
+const APIKeyHeader = "X-API-Key"
+
+const (
+	apiKeyCacheKeyPrefix  = "auth:apikey:"
+	apiKeyRateLimitPrefix = "ratelimit:apikey:"
+	apiKeyIDContextKey    = "api_key_id"
+)
+
+var errAPIKeyRevoked = errors.New("api key revoked")
+
+// HashAPIKey returns the digest an API key is stored and looked up by. Keys are
+// long random strings, so a plain SHA-256 is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// InvalidateAPIKey drops the cached lookup of the key with the given hash, so
+// a revocation applies to the next request.
+func InvalidateAPIKey(ctx context.Context, keyHash string) {
+	utils.CacheDel(ctx, apiKeyCacheKeyPrefix+keyHash)
+}
+
+// APIKeyID returns the ID of the API key the request was authenticated with,
+// or 0 for bearer tokens.
+func APIKeyID(c *gin.Context) uint {
+	return c.GetUint(apiKeyIDContextKey)
+}
+
+// RequireTokenAuth rejects requests authenticated with an API key, for
+// endpoints such as key management that need a login session. It must run
+// after AuthMiddleware.
+func RequireTokenAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if APIKeyID(c) != 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this endpoint cannot be used with an API key"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// apiKeyOwner is what authenticating with a key needs, cached per key hash.
+type apiKeyOwner struct {
+	KeyID    uint   `json:"key_id"`
+	Username string `json:"username"`
+	UserID   uint   `json:"user_id"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// authenticateAPIKey resolves the X-API-Key header to its user, setting the
+// same context values a bearer token would. It writes the error response and
+// returns false when the request must not go on.
+func authenticateAPIKey(c *gin.Context, key string) bool {
+	ctx := c.Request.Context()
+	owner, err := lookupAPIKey(ctx, HashAPIKey(key))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_api_key"})
+		return false
+	}
+	if owner.ReadOnly && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is read-only"})
+		return false
+	}
+	if !allowAPIKeyRequest(c, owner.KeyID) {
+		return false
+	}
+
+	c.Set(apiKeyIDContextKey, owner.KeyID)
+	c.Set("username", owner.Username)
+	c.Set("user_id", owner.UserID)
+	return true
+}
+
+// lookupAPIKey finds the live key with the given hash, from the cache when
+// possible.
+func lookupAPIKey(ctx context.Context, keyHash string) (*apiKeyOwner, error) {
+	cacheKey := apiKeyCacheKeyPrefix + keyHash
+	var owner apiKeyOwner
+	if cached, hit := utils.CacheGet(ctx, cacheKey); hit && json.Unmarshal([]byte(cached), &owner) == nil {
+		return &owner, nil
+	}
+
+	var key models.APIKey
+	if err := global.DB.WithContext(ctx).Preload("User").
+		Where("key_hash = ?", keyHash).
+		First(&key).Error; err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, errAPIKeyRevoked
+	}
+	owner = apiKeyOwner{KeyID: key.ID, Username: key.User.Username, UserID: key.UserID, ReadOnly: key.ReadOnly}
+	if data, err := json.Marshal(owner); err == nil {
+		utils.CacheSet(ctx, cacheKey, data, authUserCacheTTL())
+	}
+	return &owner, nil
+}
+
+// allowAPIKeyRequest counts the request against the key's per-minute limit
+// (auth.apiKeyRateLimit) and answers 429 once it is used up. The first
+// request of each minute also records when the key was last used. Requests
+// are let through while Redis is unavailable.
+func allowAPIKeyRequest(c *gin.Context, keyID uint) bool {
+	limit := config.AppConfig.Auth.APIKeyRateLimit
+	now := time.Now()
+	window := now.Truncate(time.Minute)
+	counterKey := apiKeyRateLimitPrefix + strconv.FormatUint(uint64(keyID), 10) + ":" + strconv.FormatInt(window.Unix(), 10)
+
+	ctx := c.Request.Context()
+	pipe := global.RedisDB.TxPipeline()
+	incr := pipe.Incr(ctx, counterKey)
+	pipe.Expire(ctx, counterKey, time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		global.Logger.Warn("API key rate limiting unavailable", "key_id", keyID, "error", err)
+		return true
+	}
+
+	count := incr.Val()
+	if count == 1 {
+		go touchAPIKey(keyID, now)
+	}
+	if limit <= 0 || count <= int64(limit) {
+		return true
+	}
+	c.Header("Retry-After", strconv.Itoa(int(window.Add(time.Minute).Sub(now).Seconds())+1))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error": "API key rate limit exceeded",
+		"limit": limit,
+	})
+	return false
+}
+
+func touchAPIKey(keyID uint, at time.Time) {
+	if err := global.DB.Model(&models.APIKey{}).Where("id = ?", keyID).
+		UpdateColumn("last_used_at", at).Error; err != nil {
+		global.Logger.Warn("Failed to record API key use", "key_id", keyID, "error", err)
+	}
+}
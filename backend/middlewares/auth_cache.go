@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/utils"
+)
+
+// authUserKeyPrefix is versioned: entries of an earlier format lack fields
+// such as Active and must not be read as the current one.
+const authUserKeyPrefix = "auth:user:v2:"
+
+// authUser is the part of a user that AuthMiddleware puts in the request
+// context, cached per username so most requests skip the users table.
+type authUser struct {
+	ID            uint   `json:"id"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	Active        bool   `json:"active"`
+}
+
+// lookupAuthUser resolves a username from the cache, falling back to the database.
+func lookupAuthUser(ctx context.Context, username string) (*authUser, error) {
+	key := authUserKeyPrefix + username
+	var au authUser
+	if cached, hit := utils.CacheGet(ctx, key); hit && json.Unmarshal([]byte(cached), &au) == nil {
+		return &au, nil
+	}
+
+	var user models.User
+	if err := global.DB.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	au = authUser{ID: user.ID, Role: user.Role, EmailVerified: user.EmailVerified, Active: user.Active}
+	if data, err := json.Marshal(au); err == nil {
+		utils.CacheSet(ctx, key, data, authUserCacheTTL())
+	}
+	return &au, nil
+}
+
+// InvalidateAuthUser drops the cached lookup for username. Call it whenever the
+// user's role, verification status, active flag or existence changes.
+func InvalidateAuthUser(ctx context.Context, username string) {
+	utils.CacheDel(ctx, authUserKeyPrefix+username)
+}
+
+// authUserCacheTTL bounds how long a change made elsewhere can go unnoticed
+// (one minute by default).
+func authUserCacheTTL() time.Duration {
+	if ttl := config.AppConfig.Auth.UserCacheTTL; ttl > 0 {
+		return ttl
+	}
+	return time.Minute
+}
@@ -1,39 +1,109 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/JerryLinyx/FinGOAT/db"
 	"github.com/JerryLinyx/FinGOAT/global"
-	"github.com/JerryLinyx/FinGOAT/models"
 	"github.com/JerryLinyx/FinGOAT/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+const authUserContextKey = "auth_user"
+
+// AuthMiddleware authenticates the request with the bearer token in the
+// Authorization header or, when there is none, the API key in X-API-Key.
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.GetHeader("Authorization")
 		if token == "" {
+			if key := c.GetHeader(APIKeyHeader); key != "" {
+				if !authenticateAPIKey(c, key) {
+					return
+				}
+				checkAuthUser(c)
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
-		username, err := utils.ParseJWT(token)
+		claims, err := utils.ParseJWT(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			c.Abort()
+			// Expired tokens get their own error so clients know to refresh rather than log out
+			if errors.Is(err, utils.ErrTokenExpired) {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token", error_description="token expired"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token_expired"})
+				return
+			}
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
 			return
 		}
 
-		// Find user in database to get user ID
-		var user models.User
-		if err := global.DB.Where("username = ?", username).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-			c.Abort()
-			return
+		c.Set("username", claims.Username)
+		if claims.UserID != 0 {
+			// Tokens without an ID claim predate it and are resolved by username alone
+			c.Set("user_id", claims.UserID)
 		}
+		checkAuthUser(c)
+	}
+}
+
+// checkAuthUser lets the request through if the authenticated account exists
+// and is active.
+func checkAuthUser(c *gin.Context) {
+	// The account is checked on every request (through the lookup cache)
+	// so deactivation and role changes apply to tokens already issued
+	user, err := currentAuthUser(c)
+	if err != nil {
+		abortAuthUserError(c, err)
+		return
+	}
+	if !user.Active {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "account is deactivated"})
+		return
+	}
+	c.Next()
+}
+
+// errTokenAccountMismatch rejects a token whose user ID belongs to another
+// account than its username, e.g. one deleted and registered again.
+var errTokenAccountMismatch = errors.New("token does not match the account")
 
-		c.Set("username", username)
-		c.Set("user_id", user.ID)
-		c.Next()
+// abortAuthUserError answers a failed currentAuthUser. Only a missing or
+// mismatched account is a 401; a failed lookup, such as during a database
+// outage, must not make clients discard a valid token.
+func abortAuthUserError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, errTokenAccountMismatch) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
 	}
+	status, message := db.StatusForError(err)
+	global.Logger.Error("Failed to load the authenticated user", "request_id", c.GetString("request_id"), "error", err)
+	c.AbortWithStatusJSON(status, gin.H{"error": message})
+}
+
+// currentAuthUser loads the authenticated account (through the lookup cache)
+// once per request and refreshes role and email_verified in the context from
+// it. A token whose ID no longer matches the username's account is rejected.
+func currentAuthUser(c *gin.Context) (*authUser, error) {
+	if v, ok := c.Get(authUserContextKey); ok {
+		return v.(*authUser), nil
+	}
+	user, err := lookupAuthUser(c.Request.Context(), c.GetString("username"))
+	if err != nil {
+		return nil, err
+	}
+	if id, ok := c.Get("user_id"); ok && id.(uint) != user.ID {
+		return nil, errTokenAccountMismatch
+	}
+
+	c.Set(authUserContextKey, user)
+	c.Set("user_id", user.ID)
+	c.Set("email_verified", user.EmailVerified)
+	c.Set("role", user.Role)
+	return user, nil
 }
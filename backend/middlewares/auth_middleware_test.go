@@ -0,0 +1,94 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/JerryLinyx/FinGOAT/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddlewareRejectsDeactivatedUser(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	user := models.User{Username: "alice", Password: "x", Active: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	token, err := utils.GenerateJWT(user.ID, user.Username, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetUint("user_id")})
+	})
+	get := func() int {
+		return testutil.Do(r, http.MethodGet, "/me", "", "Authorization", token).Code // GenerateJWT includes the scheme.Code
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("active user: status %d, want 200", code)
+	}
+	// Tokens issued before the deactivation stop working
+	if err := db.Model(&user).Update("active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	InvalidateAuthUser(context.Background(), user.Username)
+	if code := get(); code != http.StatusForbidden {
+		t.Fatalf("deactivated user: status %d, want 403", code)
+	}
+
+	// So do tokens of deleted accounts
+	if err := db.Unscoped().Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	InvalidateAuthUser(context.Background(), user.Username)
+	if code := get(); code != http.StatusUnauthorized {
+		t.Fatalf("deleted user: status %d, want 401", code)
+	}
+}
+
+func TestAuthMiddlewareLookupFailureIsNotUnauthorized(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	testutil.Redis(t)
+	user := models.User{Username: "alice", Password: "x", Active: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	token, err := utils.GenerateJWT(user.ID, user.Username, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close() // the database is down
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	if code := testutil.Do(r, http.MethodGet, "/me", "", "Authorization", token).Code; code != http.StatusInternalServerError {
+		t.Fatalf("status %d with the database down, want 500", code)
+	}
+}
+
+func TestAuthMiddlewareRequiresCredentials(t *testing.T) {
+	testutil.Config(t)
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	if w := testutil.Do(r, http.MethodGet, "/me", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no credentials: status %d, want 401", w.Code)
+	}
+	w := testutil.Do(r, http.MethodGet, "/me", "", "Authorization", "Bearer not-a-token")
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("invalid token: status %d, WWW-Authenticate %q, want 401 with a challenge", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func bodyLimitRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(BodyLimit(16, map[string]int64{"/import": 64}))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%s", body)
+	}
+	r.POST("/items", echo)
+	r.POST("/import", echo)
+	return r
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := bodyLimitRouter()
+	small := `{"a":"b"}`
+	large := `{"a":"` + strings.Repeat("x", 32) + `"}`
+
+	if w := testutil.Do(r, http.MethodPost, "/items", small); w.Code != http.StatusOK || w.Body.String() != small {
+		t.Fatalf("body under the limit: status %d, body %q", w.Code, w.Body)
+	}
+	if w := testutil.Do(r, http.MethodPost, "/items", large); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("body over the limit: status %d, want 413", w.Code)
+	}
+	// Routes with their own limit
+	if w := testutil.Do(r, http.MethodPost, "/import", large); w.Code != http.StatusOK {
+		t.Fatalf("body under the route's limit: status %d, want 200", w.Code)
+	}
+}
+
+func TestBodyLimitWithoutContentLength(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/items", io.NopCloser(strings.NewReader(strings.Repeat("x", 32))))
+	req.ContentLength = -1 // chunked
+	w := httptest.NewRecorder()
+	bodyLimitRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status %d for an oversized chunked body, want 413", w.Code)
+	}
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail rejects users who have not verified their email address.
+// It must run after AuthMiddleware and is a no-op unless
+// email.requireVerification is enabled.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.AppConfig.Email.RequireVerification {
+			c.Next()
+			return
+		}
+		if _, err := currentAuthUser(c); err != nil {
+			abortAuthUserError(c, err)
+			return
+		}
+		if !c.GetBool("email_verified") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "email address not verified"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
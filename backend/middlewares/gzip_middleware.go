@@ -0,0 +1,153 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gzip compresses responses for clients that accept gzip. Bodies smaller than
+// minSize are sent as-is, since compressing them usually makes them larger.
+// Server-sent event streams and websocket upgrades are never compressed, and a
+// handler that flushes before minSize bytes were written is streamed uncompressed.
+func Gzip(level, minSize int) gin.HandlerFunc {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	if minSize < 0 {
+		minSize = 0
+	}
+
+	return func(c *gin.Context) {
+		if !shouldGzip(c.Request) {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Accept-Encoding")
+		gw := &gzipResponseWriter{ResponseWriter: c.Writer, level: level, minSize: minSize}
+		c.Writer = gw
+		defer func() {
+			// On panic, discard a still-buffered body and hand the plain writer
+			// back so Recovery can send its own response
+			if rec := recover(); rec != nil {
+				if gw.gz == nil && !gw.passthrough {
+					c.Writer = gw.ResponseWriter
+					c.Writer.Header().Del("Vary")
+				} else {
+					gw.finish()
+				}
+				panic(rec)
+			}
+			gw.finish()
+		}()
+
+		c.Next()
+	}
+}
+
+func shouldGzip(req *http.Request) bool {
+	if req.Method == http.MethodHead {
+		return false
+	}
+	if !strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+		return false
+	}
+	if strings.Contains(strings.ToLower(req.Header.Get("Connection")), "upgrade") {
+		return false
+	}
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		return false
+	}
+	return true
+}
+
+// gzipResponseWriter buffers the start of the body until it knows whether the
+// response is large enough to compress, then commits to gzip or passthrough.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	level       int
+	minSize     int
+	buf         []byte
+	gz          *gzip.Writer
+	passthrough bool
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(data)
+	case w.passthrough:
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.commit(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush commits to passthrough when nothing has been compressed yet, so that
+// streaming handlers are never held back by the size threshold.
+func (w *gzipResponseWriter) Flush() {
+	if w.gz == nil && !w.passthrough {
+		_ = w.commit(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipResponseWriter) commit(compress bool) error {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" ||
+		strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") ||
+		!bodyAllowed(w.Status()) {
+		compress = false
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if !compress {
+		w.passthrough = true
+		if len(buf) == 0 {
+			return nil
+		}
+		_, err := w.ResponseWriter.Write(buf)
+		return err
+	}
+
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	gz, err := gzip.NewWriterLevel(w.ResponseWriter, w.level)
+	if err != nil {
+		return err
+	}
+	w.gz = gz
+	_, err = w.gz.Write(buf)
+	return err
+}
+
+func (w *gzipResponseWriter) finish() {
+	if w.gz == nil && !w.passthrough {
+		_ = w.commit(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified &&
+		(status < 100 || status >= 200)
+}
@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func gzipRouter(body string) *gin.Engine {
+	r := gin.New()
+	r.Use(Gzip(gzip.DefaultCompression, 1024))
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"body": body})
+	})
+	return r
+}
+
+func TestGzipCompressesLargeResponses(t *testing.T) {
+	body := strings.Repeat("fingoat ", 1000)
+	w := testutil.Do(gzipRouter(body), http.MethodGet, "/", "", "Accept-Encoding", "gzip, deflate")
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct{ Body string }
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("decompressed body is not JSON: %v", err)
+	}
+	if resp.Body != body {
+		t.Fatal("decompressed body differs from the response written")
+	}
+}
+
+func TestGzipSkipsSmallResponses(t *testing.T) {
+	w := testutil.Do(gzipRouter("small"), http.MethodGet, "/", "", "Accept-Encoding", "gzip")
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("Content-Encoding = %q for a small body, want none", got)
+	}
+	if !strings.Contains(w.Body.String(), `"small"`) {
+		t.Fatalf("body = %q, want the plain JSON", w.Body)
+	}
+}
+
+func TestGzipRequiresAcceptEncoding(t *testing.T) {
+	w := testutil.Do(gzipRouter(strings.Repeat("x", 4096)), http.MethodGet, "/", "")
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("Content-Encoding = %q without Accept-Encoding, want none", got)
+	}
+}
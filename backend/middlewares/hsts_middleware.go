@@ -0,0 +1,15 @@
+package middlewares
+
+import "github.com/gin-gonic/gin"
+
+// hstsValue asks browsers to use HTTPS for this host for a year.
+const hstsValue = "max-age=31536000; includeSubDomains"
+
+// HSTS sets the Strict-Transport-Security header. Only register it when the
+// server terminates TLS itself; browsers ignore the header over plain HTTP.
+func HSTS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Strict-Transport-Security", hstsValue)
+		c.Next()
+	}
+}
@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+const internalSecret = "s3cret"
+
+func internalRouter(secret string) *gin.Engine {
+	r := gin.New()
+	r.POST("/internal", InternalAuth(secret, time.Minute), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%s", body)
+	})
+	return r
+}
+
+// sign returns the signature headers for body sent at ts.
+func sign(secret string, ts time.Time, body string) []string {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return []string{
+		InternalTimestampHeader, timestamp,
+		InternalSignatureHeader, "sha256=" + hex.EncodeToString(mac.Sum(nil)),
+	}
+}
+
+func TestInternalAuthSecret(t *testing.T) {
+	r := internalRouter(internalSecret)
+	const body = `{"task_id":"t1"}`
+
+	tests := []struct {
+		name    string
+		headers []string
+		want    int
+	}{
+		{"missing", nil, http.StatusUnauthorized},
+		{"wrong", []string{InternalSecretHeader, "guess"}, http.StatusUnauthorized},
+		{"prefix of the secret", []string{InternalSecretHeader, internalSecret[:3]}, http.StatusUnauthorized},
+		{"correct", []string{InternalSecretHeader, internalSecret}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := testutil.Do(r, http.MethodPost, "/internal", body, tt.headers...); w.Code != tt.want {
+			t.Errorf("%s secret: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestInternalAuthDisabledWithoutSecret(t *testing.T) {
+	w := testutil.Do(internalRouter(""), http.MethodPost, "/internal", "{}", InternalSecretHeader, "")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status %d with no secret configured, want 404", w.Code)
+	}
+}
+
+func TestInternalAuthSignature(t *testing.T) {
+	r := internalRouter(internalSecret)
+	const body = `{"task_id":"t1"}`
+	now := time.Now()
+
+	w := testutil.Do(r, http.MethodPost, "/internal", body, sign(internalSecret, now, body)...)
+	if w.Code != http.StatusOK {
+		t.Fatalf("valid signature: status %d, want 200: %s", w.Code, w.Body)
+	}
+	if w.Body.String() != body {
+		t.Fatalf("handler read body %q, want %q", w.Body, body)
+	}
+
+	tests := []struct {
+		name    string
+		headers []string
+	}{
+		{"wrong key", sign("other", now, body)},
+		{"different body", sign(internalSecret, now, `{"task_id":"t2"}`)},
+		{"too old", sign(internalSecret, now.Add(-2*time.Minute), body)},
+		{"too far ahead", sign(internalSecret, now.Add(2*time.Minute), body)},
+		{"no timestamp", []string{InternalSignatureHeader, sign(internalSecret, now, body)[3]}},
+		{"malformed", []string{InternalTimestampHeader, strconv.FormatInt(now.Unix(), 10), InternalSignatureHeader, "md5=abc"}},
+	}
+	for _, tt := range tests {
+		if w := testutil.Do(r, http.MethodPost, "/internal", body, tt.headers...); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status %d, want 401", tt.name, w.Code)
+		}
+	}
+
+	// Within the allowed skew
+	if w := testutil.Do(r, http.MethodPost, "/internal", body, sign(internalSecret, now.Add(-30*time.Second), body)...); w.Code != http.StatusOK {
+		t.Fatalf("timestamp 30s old: status %d, want 200", w.Code)
+	}
+}
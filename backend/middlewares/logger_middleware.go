@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/gin-gonic/gin"
+)
+
+// Logger emits one structured line per request. Only the path is logged (not the
+// query string, headers or body) so tokens and passwords never reach the logs.
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+			"request_id", c.GetString("request_id"),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		global.Logger.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
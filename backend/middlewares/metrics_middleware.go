@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records request count, latency and in-flight requests per route.
+// The route template (e.g. /api/articles/:id) is used as the label so that
+// path parameters don't blow up label cardinality. A panicking handler is
+// counted as the 500 that Recovery, mounted outside, answers it with.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		inFlight := metrics.HTTPRequestsInFlight.WithLabelValues(route)
+		inFlight.Inc()
+		start := time.Now()
+
+		defer func() {
+			inFlight.Dec()
+			code := c.Writer.Status()
+			p := recover()
+			if p != nil {
+				code = http.StatusInternalServerError
+			}
+			status := strconv.Itoa(code)
+			metrics.HTTPRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+			metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		c.Next()
+	}
+}
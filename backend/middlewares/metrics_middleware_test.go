@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/metrics"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsCountsPanicsAsServerErrors(t *testing.T) {
+	testutil.Config(t)
+	r := gin.New()
+	r.Use(Recovery(), Metrics())
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	serverErrors := metrics.HTTPRequestsTotal.WithLabelValues(http.MethodGet, "/panic", "500")
+	before := promtest.ToFloat64(serverErrors)
+	if w := testutil.Do(r, http.MethodGet, "/panic", ""); w.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", w.Code)
+	}
+	if n := promtest.ToFloat64(serverErrors) - before; n != 1 {
+		t.Fatalf("panic counted %v times as a 500, want once", n)
+	}
+	if n := promtest.ToFloat64(metrics.HTTPRequestsInFlight.WithLabelValues("/panic")); n != 0 {
+		t.Fatalf("%v requests still in flight, want the panicking one released", n)
+	}
+}
@@ -0,0 +1,123 @@
+package middlewares
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Media types offered by endpoints that render more than one format.
+const (
+	MediaJSON = "application/json"
+	MediaCSV  = "text/csv"
+)
+
+const negotiatedTypeKey = "negotiated_type"
+
+// Negotiate picks the response media type from offers according to the
+// request's Accept header, honouring q-values and wildcards, and stores it for
+// NegotiatedType. Offers are listed in order of preference; the first is used
+// when the client sends no Accept header or accepts them equally. A request
+// that accepts none of them is rejected with 406.
+func Negotiate(offers ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mediaType := negotiate(c.GetHeader("Accept"), offers)
+		if mediaType == "" {
+			c.JSON(http.StatusNotAcceptable, gin.H{
+				"error":     "none of the requested media types can be produced",
+				"supported": offers,
+			})
+			c.Abort()
+			return
+		}
+		c.Set(negotiatedTypeKey, mediaType)
+		c.Next()
+	}
+}
+
+// NegotiatedType returns the media type chosen by Negotiate, or "" when the
+// route does not use it.
+func NegotiatedType(c *gin.Context) string {
+	return c.GetString(negotiatedTypeKey)
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+func negotiate(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		r := acceptRange{mediaType: strings.ToLower(strings.TrimSpace(fields[0])), q: 1}
+		for _, param := range fields[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		if r.mediaType != "" {
+			ranges = append(ranges, r)
+		}
+	}
+	// Most preferred first; among equals, the more specific range first
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return specificity(ranges[i].mediaType) > specificity(ranges[j].mediaType)
+	})
+
+	for _, r := range ranges {
+		if r.q <= 0 {
+			break
+		}
+		for _, offer := range offers {
+			if mediaMatches(r.mediaType, offer) && !excluded(ranges, offer) {
+				return offer
+			}
+		}
+	}
+	return ""
+}
+
+// excluded reports whether offer is explicitly refused with q=0.
+func excluded(ranges []acceptRange, offer string) bool {
+	for _, r := range ranges {
+		if r.q <= 0 && r.mediaType == offer {
+			return true
+		}
+	}
+	return false
+}
+
+func mediaMatches(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return false
+}
+
+func specificity(mediaType string) int {
+	switch {
+	case mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
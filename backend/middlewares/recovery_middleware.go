@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns a panic in a handler into a 500 with the usual JSON error body
+// and logs the panic value and stack trace with the request ID. Must be
+// registered after RequestID so the ID is available.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Propagated on purpose by net/http to abort a response
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			brokenPipe := isBrokenPipe(rec)
+			global.Logger.Error("Panic recovered",
+				"panic", fmt.Sprint(rec),
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"request_id", c.GetString("request_id"),
+				"broken_pipe", brokenPipe,
+				"stack", string(debug.Stack()),
+			)
+			c.Error(fmt.Errorf("panic: %v", rec))
+
+			// The client is gone or the response already started
+			if brokenPipe || c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}()
+		c.Next()
+	}
+}
+
+func isBrokenPipe(rec any) bool {
+	err, ok := rec.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var sysErr *os.SyscallError
+	if errors.As(opErr, &sysErr) && (errors.Is(sysErr.Err, syscall.EPIPE) || errors.Is(sysErr.Err, syscall.ECONNRESET)) {
+		return true
+	}
+	msg := strings.ToLower(opErr.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Users are created with RoleUser. RoleAdmin is allowed to use administrative
+// endpoints; admins promote other users through PATCH /admin/users/:id.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// RequireRole rejects users whose role differs from role. It must run after
+// AuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Check the account's current role, not the one in the token
+		if _, err := currentAuthUser(c); err != nil {
+			abortAuthUserError(c, err)
+			return
+		}
+		if c.GetString("role") != role {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// SlowRequests logs a warning for each request that takes longer than
+// threshold and counts it in fingoat_slow_requests_total. WebSocket
+// connections are long-lived by design and are not reported.
+func SlowRequests(threshold time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.IsWebsocket() {
+			c.Next()
+			return
+		}
+		start := time.Now()
+
+		c.Next()
+
+		elapsed := time.Since(start)
+		if elapsed <= threshold {
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.SlowRequestsTotal.WithLabelValues(c.Request.Method, route).Inc()
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", route,
+			"status", c.Writer.Status(),
+			"duration_ms", elapsed.Milliseconds(),
+			"threshold_ms", threshold.Milliseconds(),
+			"request_id", c.GetString("request_id"),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			attrs = append(attrs, "user_id", userID)
+		}
+		global.Logger.WarnContext(c.Request.Context(), "slow request", attrs...)
+	}
+}
@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout gives each request a context deadline of d, or of the override for
+// its route (keyed by lower-cased route pattern, as in c.FullPath()). A zero
+// duration disables the deadline. Database and upstream calls made with
+// c.Request.Context() are cancelled when it passes.
+//
+// Handlers run to completion on the request goroutine; once the deadline has
+// passed, whatever they write is replaced by a 503. Websocket upgrades are
+// long-lived and never get a deadline.
+func Timeout(d time.Duration, overrides map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := d
+		if override, ok := overrides[strings.ToLower(c.FullPath())]; ok {
+			timeout = override
+		}
+		if timeout <= 0 || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		tw := &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx}
+		c.Writer = tw
+		c.Next()
+		c.Writer = tw.ResponseWriter
+
+		// Handlers that returned without writing, e.g. after an aborted query
+		if !tw.Written() && tw.expired() {
+			tw.writeTimeout()
+		}
+	}
+}
+
+// timeoutWriter swaps the handler's response for a 503 if the request's
+// deadline has passed by the time the handler starts writing it.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+func (w *timeoutWriter) expired() bool {
+	return errors.Is(w.ctx.Err(), context.DeadlineExceeded)
+}
+
+// intercept reports whether the handler's output must be dropped, writing the
+// 503 the first time.
+func (w *timeoutWriter) intercept() bool {
+	if w.timedOut {
+		return true
+	}
+	if w.ResponseWriter.Written() || !w.expired() {
+		return false
+	}
+	w.writeTimeout()
+	return true
+}
+
+func (w *timeoutWriter) writeTimeout() {
+	w.timedOut = true
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.ResponseWriter.Write([]byte(`{"error":"request timed out"}`))
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if !w.intercept() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if w.intercept() {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.intercept() {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
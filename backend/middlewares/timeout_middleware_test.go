@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	r := gin.New()
+	r.Use(Timeout(20*time.Millisecond, map[string]time.Duration{"/export": 0}))
+	slow := func(c *gin.Context) {
+		time.Sleep(50 * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{"done": true})
+	}
+	r.GET("/slow", slow)
+	r.GET("/export", slow)
+	r.GET("/fast", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"done": true}) })
+	// Stops early because its context was cancelled, without writing anything
+	r.GET("/cancelled", func(c *gin.Context) { <-c.Request.Context().Done() })
+
+	w := testutil.Do(r, http.MethodGet, "/slow", "")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "timed out") {
+		t.Fatalf("slow handler: status %d, body %q, want 503", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "done") {
+		t.Fatalf("slow handler's response leaked into the 503: %q", w.Body)
+	}
+	if w := testutil.Do(r, http.MethodGet, "/cancelled", ""); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("handler that wrote nothing: status %d, want 503", w.Code)
+	}
+	if w := testutil.Do(r, http.MethodGet, "/fast", ""); w.Code != http.StatusOK {
+		t.Fatalf("fast handler: status %d, want 200", w.Code)
+	}
+	// A zero override disables the deadline for the route
+	if w := testutil.Do(r, http.MethodGet, "/export", ""); w.Code != http.StatusOK {
+		t.Fatalf("route without a deadline: status %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,24 @@
+package middlewares
+
+import "github.com/gin-gonic/gin"
+
+// APIVersionHeader tells clients which API version served the response.
+const APIVersionHeader = "X-API-Version"
+
+// APIVersion sets the API version header on every response of a route group.
+func APIVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(APIVersionHeader, version)
+		c.Next()
+	}
+}
+
+// Deprecated marks every response of a route group as deprecated and points
+// clients at the prefix that replaces it.
+func Deprecated(successorPrefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successorPrefix+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
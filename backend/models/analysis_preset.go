@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+// AnalysisPreset is a named analysis config a user can apply to requests
+// instead of repeating the settings each time.
+type AnalysisPreset struct {
+	gorm.Model
+	UserID uint   `gorm:"not null;uniqueIndex:idx_analysis_presets_user_name,where:deleted_at IS NULL" json:"user_id"`
+	Name   string `gorm:"type:varchar(100);not null;uniqueIndex:idx_analysis_presets_user_name,where:deleted_at IS NULL" json:"name"`
+	Config string `gorm:"type:jsonb;not null" json:"-"` // JSON object forwarded as the analysis config
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
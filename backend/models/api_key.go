@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey lets a user call the API from scripts instead of with a JWT. Only the
+// SHA-256 of the key is stored; the key itself is shown once, when created.
+// Revoked keys are kept so their use remains visible in listings.
+type APIKey struct {
+	gorm.Model
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Label      string     `gorm:"type:varchar(100);not null" json:"label"`
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"` // start of the key, to tell keys apart
+	KeyHash    string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ReadOnly   bool       `gorm:"not null;default:false" json:"read_only"` // limited to GET and HEAD requests
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
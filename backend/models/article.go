@@ -1,10 +1,45 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Article struct {
 	gorm.Model
-	Title   string `binding:"required"`
-	Content string `binding:"required"`
-	Preview string `binding:"required"`
+	Title       string     `binding:"required"`
+	Content     string     `binding:"required"`
+	Preview     string     `binding:"required"`
+	Link        string     `gorm:"type:text;uniqueIndex:idx_articles_link,where:link <> ''" binding:"omitempty,url"` // original URL, if imported
+	PublishedAt *time.Time `gorm:"index"`                                                                            // original publication time, if known
+	// Set when articles are deduplicated by content (articles.dedup: content);
+	// DuplicateContentHash for duplicates found when hashes were backfilled
+	ContentHash *string `gorm:"type:char(64);uniqueIndex:idx_articles_content_hash" json:"-"`
+
+	// Managed through the tag endpoints. Join rows survive a soft delete (so a
+	// restored article keeps its tags) and cascade when the article is purged.
+	Tags []Tag `gorm:"many2many:article_tags;constraint:OnDelete:CASCADE"`
+}
+
+// ArticleContentHash fingerprints an article by its title and content, ignoring
+// case and whitespace differences, so a story republished under another link
+// hashes the same.
+func ArticleContentHash(title, content string) string {
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	}
+	sum := sha256.Sum256([]byte(normalize(title) + "\n" + normalize(content)))
+	return hex.EncodeToString(sum[:])
+}
+
+// DuplicateContentHash is stored as the content hash of an article found, when
+// hashes were backfilled, to duplicate an older one. It is unique per article
+// and never equals a real hash, so it only marks the article as considered.
+func DuplicateContentHash(id uint) string {
+	return "duplicate:" + strconv.FormatUint(uint64(id), 10)
 }
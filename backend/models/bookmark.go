@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Bookmark is an article a user saved for later. A user can bookmark an
+// article once.
+type Bookmark struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_bookmarks_user_article" json:"user_id"`
+	ArticleID uint      `gorm:"not null;uniqueIndex:idx_bookmarks_user_article;index" json:"article_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Article Article `gorm:"constraint:OnDelete:CASCADE" json:"article"`
+}
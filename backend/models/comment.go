@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+// Comment is a user's comment on an article. Deleting a comment is a soft
+// delete, so the row is kept for moderation.
+type Comment struct {
+	gorm.Model
+	ArticleID uint   `gorm:"not null;index" json:"article_id"`
+	UserID    uint   `gorm:"not null;index" json:"user_id"`
+	Body      string `gorm:"type:text;not null" json:"body"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
@@ -2,10 +2,16 @@ package models
 
 import "time"
 
+// ExchangeRate is the rate of one currency pair at a point in time: one unit
+// of FromCurrency (the base) buys Rate units of ToCurrency (the quote).
+//
+// A pair has at most one rate per UTC day, keyed by Day (YYYY-MM-DD), which
+// is derived from Date when the rate is saved.
 type ExchangeRate struct {
 	ID           uint      `gorm:"primaryKey" json:"_id"`
-	FromCurrency string    `json:"fromCurrency" binding:"required"`
-	ToCurrency   string    `json:"toCurrency" binding:"required"`
+	FromCurrency string    `gorm:"index:idx_exchange_rates_pair_date,priority:1;uniqueIndex:idx_exchange_rates_pair_day,priority:1" json:"fromCurrency" binding:"required"`
+	ToCurrency   string    `gorm:"index:idx_exchange_rates_pair_date,priority:2;uniqueIndex:idx_exchange_rates_pair_day,priority:2" json:"toCurrency" binding:"required"`
 	Rate         float64   `json:"rate" binding:"required"`
-	Date         time.Time `json:"date"`
+	Date         time.Time `gorm:"index:idx_exchange_rates_pair_date,priority:3" json:"date"`
+	Day          string    `gorm:"size:10;uniqueIndex:idx_exchange_rates_pair_day,priority:3" json:"-"`
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is a webhook delivery waiting to be sent. Events are written in
+// the same transaction as the change they announce and removed from the queue
+// only once delivered or dead-lettered, so none are lost across restarts.
+type OutboxEvent struct {
+	gorm.Model
+	UserID        uint       `gorm:"not null;index" json:"user_id"`
+	TaskID        string     `gorm:"type:varchar(100);not null;index" json:"task_id"`
+	Event         string     `gorm:"type:varchar(50);not null" json:"event"`
+	URL           string     `gorm:"type:text;not null" json:"url"`
+	Payload       string     `gorm:"type:jsonb;not null" json:"-"`
+	Status        string     `gorm:"type:varchar(20);not null;index:idx_outbox_events_due,priority:1" json:"status"` // pending/delivered/failed
+	Attempts      int        `gorm:"not null" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_events_due,priority:2" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"gorm.io/gorm"
+)
+
+// CompressAnalysisReports makes decisions store their analysis report
+// gzip-compressed in analysis_report_gz rather than as JSONB. It is set from
+// trading.compressReports at startup. Reports are read back from whichever
+// column holds them, so the setting can be changed at any time.
+var CompressAnalysisReports bool
+
+// CompressReport gzips an analysis report.
+func CompressReport(report string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, report); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressReport reverses CompressReport.
+func DecompressReport(data []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	report, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(report), nil
+}
+
+// BeforeSave stores the report in the column CompressAnalysisReports selects
+// and clears the other, so a row never holds two versions of it.
+func (d *TradingDecision) BeforeSave(tx *gorm.DB) error {
+	d.AnalysisReportGz = nil
+	if !CompressAnalysisReports || d.AnalysisReport == nil {
+		return nil
+	}
+	compressed, err := CompressReport(*d.AnalysisReport)
+	if err != nil {
+		return fmt.Errorf("compress analysis report: %w", err)
+	}
+	d.AnalysisReportGz = compressed
+	d.uncompressedReport, d.AnalysisReport = d.AnalysisReport, nil
+	return nil
+}
+
+// AfterSave puts back the report BeforeSave moved out of the JSONB column, so
+// callers still see it.
+func (d *TradingDecision) AfterSave(tx *gorm.DB) error {
+	if d.uncompressedReport != nil {
+		d.AnalysisReport, d.uncompressedReport = d.uncompressedReport, nil
+	}
+	return nil
+}
+
+// AfterFind decompresses a report stored in analysis_report_gz.
+func (d *TradingDecision) AfterFind(tx *gorm.DB) error {
+	if d.AnalysisReport != nil || len(d.AnalysisReportGz) == 0 {
+		return nil
+	}
+	report, err := DecompressReport(d.AnalysisReportGz)
+	if err != nil {
+		return fmt.Errorf("decompress analysis report of task %s: %w", d.TaskID, err)
+	}
+	d.AnalysisReport = &report
+	return nil
+}
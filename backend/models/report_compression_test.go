@@ -0,0 +1,185 @@
+package models_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/models"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	"gorm.io/gorm"
+)
+
+// sampleReport builds a report shaped like the trading service's: a section of
+// prose per agent. The prose is drawn at random from a finance vocabulary, so
+// it compresses no better than real analyst text would.
+func sampleReport() string {
+	words := strings.Fields(`the stock price moving average volume momentum earnings revenue
+		guidance margin growth valuation multiple support resistance breakout trend
+		analyst sentiment news outlook risk upside downside catalyst quarter fiscal
+		demand supply chain services iPhone buyback dividend cash flow debt rates
+		inflation Fed yield sector rotation investors institutional retail options
+		volatility bullish bearish neutral hold buy sell position target stop loss`)
+	rng := rand.New(rand.NewPCG(1, 2))
+	sections := map[string]string{}
+	for _, agent := range []string{"market", "sentiment", "news", "fundamentals", "investment_plan", "trader_investment_plan", "final_trade_decision"} {
+		var b strings.Builder
+		fmt.Fprintf(&b, "## %s report for AAPL on 2024-01-02\n\n", agent)
+		for range 40 {
+			for range 12 + rng.IntN(20) {
+				b.WriteString(words[rng.IntN(len(words))])
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(&b, "at %.2f on volume of %d.\n", 150+rng.Float64()*60, 30_000_000+rng.IntN(40_000_000))
+		}
+		sections[agent] = b.String()
+	}
+	report, err := json.Marshal(sections)
+	if err != nil {
+		panic(err)
+	}
+	return string(report)
+}
+
+func compressReports(t *testing.T, on bool) {
+	t.Helper()
+	prev := models.CompressAnalysisReports
+	models.CompressAnalysisReports = on
+	t.Cleanup(func() { models.CompressAnalysisReports = prev })
+}
+
+// storedReport reads the two report columns of a decision as they are in the
+// database.
+func storedReport(t *testing.T, db *gorm.DB, id uint) (sql.NullString, []byte) {
+	t.Helper()
+	var report sql.NullString
+	var gz []byte
+	if err := db.Table("trading_decisions").Select("analysis_report, analysis_report_gz").
+		Where("id = ?", id).Row().Scan(&report, &gz); err != nil {
+		t.Fatal(err)
+	}
+	return report, gz
+}
+
+func readReport(t *testing.T, db *gorm.DB, id uint) string {
+	t.Helper()
+	var decision models.TradingDecision
+	if err := db.First(&decision, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if decision.AnalysisReport == nil {
+		t.Fatal("report read back as nil")
+	}
+	return *decision.AnalysisReport
+}
+
+func TestCompressReportRoundTrip(t *testing.T) {
+	for _, report := range []string{"", `{"summary":"Hold — 持有"}`, sampleReport()} {
+		compressed, err := models.CompressReport(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := models.DecompressReport(compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != report {
+			t.Fatalf("round trip of a %d-byte report returned %d bytes that differ", len(report), len(got))
+		}
+	}
+}
+
+func TestDecompressReportRejectsUncompressedData(t *testing.T) {
+	if _, err := models.DecompressReport([]byte(`{"summary":"hold"}`)); err == nil {
+		t.Fatal("DecompressReport accepted data that is not gzip")
+	}
+}
+
+func TestDecisionReportStoredCompressed(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	compressReports(t, true)
+	report := sampleReport()
+
+	decision := models.TradingDecision{TaskID: "task-1", Action: "BUY", AnalysisReport: &report}
+	if err := db.Create(&decision).Error; err != nil {
+		t.Fatal(err)
+	}
+	if decision.AnalysisReport == nil || *decision.AnalysisReport != report {
+		t.Fatal("the saved decision no longer holds its report")
+	}
+	jsonb, gz := storedReport(t, db, decision.ID)
+	if jsonb.Valid {
+		t.Fatal("the report was also stored uncompressed")
+	}
+	if len(gz) == 0 || len(gz) >= len(report) {
+		t.Fatalf("compressed report is %d bytes for a %d-byte report", len(gz), len(report))
+	}
+	if got := readReport(t, db, decision.ID); got != report {
+		t.Fatal("the report read back differs from the one saved")
+	}
+
+	// Turning compression off leaves stored reports readable
+	models.CompressAnalysisReports = false
+	if got := readReport(t, db, decision.ID); got != report {
+		t.Fatal("the compressed report is unreadable with compression off")
+	}
+}
+
+// Rows written before compression existed keep their report in the JSONB
+// column until they are next saved.
+func TestDecisionReportStoredBeforeCompression(t *testing.T) {
+	testutil.Config(t)
+	db := testutil.DB(t)
+	compressReports(t, false)
+	report := `{"summary":"hold"}`
+
+	decision := models.TradingDecision{TaskID: "task-1", Action: "HOLD", AnalysisReport: &report}
+	if err := db.Create(&decision).Error; err != nil {
+		t.Fatal(err)
+	}
+	if jsonb, gz := storedReport(t, db, decision.ID); jsonb.String != report || gz != nil {
+		t.Fatalf("stored %q and %d compressed bytes, want only the JSONB report", jsonb.String, len(gz))
+	}
+
+	models.CompressAnalysisReports = true
+	if got := readReport(t, db, decision.ID); got != report {
+		t.Fatalf("read back %q, want %q", got, report)
+	}
+
+	// Saving the row again moves the report to the compressed column
+	var loaded models.TradingDecision
+	if err := db.First(&loaded, decision.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	loaded.Confidence = 0.5
+	if err := db.Save(&loaded).Error; err != nil {
+		t.Fatal(err)
+	}
+	if jsonb, gz := storedReport(t, db, decision.ID); jsonb.Valid || len(gz) == 0 {
+		t.Fatalf("after a save: JSONB %v, %d compressed bytes, want only the compressed report", jsonb, len(gz))
+	}
+	if got := readReport(t, db, decision.ID); got != report {
+		t.Fatalf("read back %q, want %q", got, report)
+	}
+}
+
+// BenchmarkCompressReport reports the space compression saves on a typical
+// report, as raw-bytes, gz-bytes and the ratio of the two.
+func BenchmarkCompressReport(b *testing.B) {
+	report := sampleReport()
+	var compressed []byte
+	b.SetBytes(int64(len(report)))
+	for b.Loop() {
+		var err error
+		if compressed, err = models.CompressReport(report); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(report)), "raw-bytes")
+	b.ReportMetric(float64(len(compressed)), "gz-bytes")
+	b.ReportMetric(float64(len(report))/float64(len(compressed)), "ratio")
+}
@@ -0,0 +1,20 @@
+package models
+
+import "gorm.io/gorm"
+
+// ScheduledAnalysis re-runs an analysis of Ticker on every trading day at RunAt
+// (HH:MM in the scheduler's time zone). Each run is recorded as a regular
+// TradingAnalysisTask carrying the schedule's ID.
+type ScheduledAnalysis struct {
+	gorm.Model
+	UserID      uint   `gorm:"not null;index" json:"user_id"`
+	Ticker      string `gorm:"type:varchar(10);not null" json:"ticker"`
+	RunAt       string `gorm:"type:varchar(5);not null" json:"run_at"`
+	LLMProvider string `gorm:"type:varchar(50)" json:"llm_provider,omitempty"`
+	LLMModel    string `gorm:"type:varchar(100)" json:"llm_model,omitempty"`
+	Active      bool   `gorm:"not null;index" json:"active"`
+	LastRunDate string `gorm:"type:varchar(10)" json:"last_run_date,omitempty"` // trading day of the last submission, or of a run skipped for quota
+	LastError   string `gorm:"type:text" json:"last_error,omitempty"`           // why the latest attempt failed, cleared on success
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Tag is a topic label shared by any number of articles.
+type Tag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Name      string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
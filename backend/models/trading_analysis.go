@@ -9,21 +9,29 @@ import (
 // TradingAnalysisTask represents a trading analysis task
 type TradingAnalysisTask struct {
 	gorm.Model
-	UserID                uint       `gorm:"not null;index" json:"user_id"`
-	TaskID                string     `gorm:"type:varchar(100);unique;not null;index" json:"task_id"`
-	Ticker                string     `gorm:"type:varchar(10);not null" json:"ticker"`
-	AnalysisDate          string     `gorm:"type:varchar(20);not null" json:"analysis_date"`
-	Status                string     `gorm:"type:varchar(20);not null" json:"status"` // pending/processing/completed/failed
-	Config                *string    `gorm:"type:jsonb" json:"config,omitempty"`
-	LLMProvider           string     `gorm:"type:varchar(50)" json:"llm_provider,omitempty"`
-	LLMModel              string     `gorm:"type:varchar(100)" json:"llm_model,omitempty"`
-	LLMBaseURL            string     `gorm:"type:text" json:"llm_base_url,omitempty"`
-	CompletedAt           *time.Time `json:"completed_at,omitempty"`
-	ProcessingTimeSeconds float64    `json:"processing_time_seconds,omitempty"`
-	Error                 string     `gorm:"type:text" json:"error,omitempty"`
+	UserID                uint                   `gorm:"not null;index" json:"user_id"`
+	TaskID                string                 `gorm:"type:varchar(100);unique;not null;index" json:"task_id"`
+	Ticker                string                 `gorm:"type:varchar(10);not null" json:"ticker"`
+	AnalysisDate          string                 `gorm:"type:varchar(20);not null" json:"analysis_date"`
+	Status                string                 `gorm:"type:varchar(20);not null" json:"status"` // pending/processing/completed/failed
+	Config                *string                `gorm:"type:jsonb" json:"config,omitempty"`
+	LLMProvider           string                 `gorm:"type:varchar(50)" json:"llm_provider,omitempty"`
+	LLMModel              string                 `gorm:"type:varchar(100)" json:"llm_model,omitempty"`
+	LLMBaseURL            string                 `gorm:"type:text" json:"llm_base_url,omitempty"`
+	CompletedAt           *time.Time             `json:"completed_at,omitempty"`
+	ProcessingTimeSeconds float64                `json:"processing_time_seconds,omitempty"`
+	Error                 string                 `gorm:"type:text" json:"error,omitempty"`
+	CallbackURL           string                 `gorm:"type:text" json:"callback_url,omitempty"`
+	WebhookStatus         string                 `gorm:"type:varchar(20);index" json:"webhook_status,omitempty"` // pending/delivered/failed
+	ScheduleID            *uint                  `gorm:"index" json:"schedule_id,omitempty"`                     // set for runs started by a ScheduledAnalysis
+	PresetID              *uint                  `json:"preset_id,omitempty"`                                    // preset the config was taken from, if any
+	DryRun                bool                   `gorm:"not null;default:false" json:"dry_run,omitempty"`        // canned result, never sent to the trading service
+	RetryOf               string                 `gorm:"type:varchar(100);index" json:"retry_of,omitempty"`      // task_id of the failed task this one re-runs
+	RetryClaimed          bool                   `gorm:"not null;default:false" json:"-"`                        // set while, and once, this task is being retried
 	AnalysisReport        map[string]interface{} `gorm:"-" json:"analysis_report,omitempty"`
 	KeyOutputs            map[string]interface{} `gorm:"-" json:"key_outputs,omitempty"`
 	StageTimes            map[string]float64     `gorm:"-" json:"stage_times,omitempty"`
+	PollAfterSeconds      int                    `gorm:"-" json:"poll_after_seconds,omitempty"` // set while active: how long clients should wait before polling again
 
 	// Relationship
 	Decision *TradingDecision `gorm:"foreignKey:TaskID;references:TaskID" json:"decision,omitempty"`
@@ -35,13 +43,17 @@ type TradingAnalysisTask struct {
 // TradingDecision represents the trading decision and analysis results
 type TradingDecision struct {
 	gorm.Model
-	TaskID       string  `gorm:"type:varchar(100);not null;index" json:"task_id"`
+	TaskID       string  `gorm:"type:varchar(100);not null;uniqueIndex:idx_trading_decisions_task_id_unique" json:"task_id"`
 	Action       string  `gorm:"type:varchar(10);not null" json:"action"` // BUY/SELL/HOLD
 	Confidence   float64 `json:"confidence"`
 	PositionSize int     `json:"position_size,omitempty"`
 
 	// Complete analysis report from all agents (stored as JSONB)
 	AnalysisReport *string `gorm:"type:jsonb" json:"analysis_report,omitempty"`
+	// The report gzip-compressed instead, when CompressAnalysisReports is set
+	AnalysisReportGz []byte `gorm:"type:bytea" json:"-"`
+	// Holds AnalysisReport while a compressed report is being saved
+	uncompressedReport *string
 
 	// Raw decision text
 	RawDecision *string `gorm:"type:jsonb" json:"raw_decision,omitempty"`
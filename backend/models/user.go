@@ -4,7 +4,15 @@ import "gorm.io/gorm"
 
 type User struct {
 	gorm.Model
-	Username string `gorm:"not null;unique"`
-	Password string `gorm:"not null"`
+	Username string `gorm:"not null;unique" json:"username"`
+	// bcrypt hash, empty for accounts created through OAuth; never serialized,
+	// return a controllers.UserProfile instead
+	Password      string `gorm:"not null" json:"-"`
+	Email         string `gorm:"type:varchar(255);uniqueIndex:idx_users_email,where:email <> ''" json:"email"`
+	EmailVerified bool   `gorm:"not null;default:false" json:"email_verified"`
+	Role          string `gorm:"type:varchar(20);not null;default:user" json:"role"` // user/admin
+	Active        bool   `gorm:"not null;default:true" json:"active"`                // inactive users cannot authenticate
+	// Set for accounts that sign in through an OAuth provider
+	OAuthProvider string `gorm:"column:oauth_provider;type:varchar(20);uniqueIndex:idx_users_oauth,where:oauth_subject <> ''" json:"oauth_provider,omitempty"`
+	OAuthSubject  string `gorm:"column:oauth_subject;type:varchar(255);uniqueIndex:idx_users_oauth,where:oauth_subject <> ''" json:"-"`
 }
-
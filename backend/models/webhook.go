@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+// WebhookSubscription holds a user's default callback URL and the secret used
+// to sign webhook payloads sent to them.
+type WebhookSubscription struct {
+	gorm.Model
+	UserID uint   `gorm:"not null;uniqueIndex" json:"user_id"`
+	URL    string `gorm:"type:text" json:"url"`
+	Secret string `gorm:"type:varchar(64);not null" json:"-"`
+}
@@ -0,0 +1,48 @@
+package pagination
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Meta describes the current page in a list response.
+type Meta struct {
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// Parse reads the page and page_size query parameters. Missing or invalid
+// values fall back to page 1 and DefaultPageSize; page_size is capped at
+// MaxPageSize.
+func Parse(c *gin.Context) (offset, limit int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err = strconv.Atoi(c.Query("page_size"))
+	if err != nil || limit < 1 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	return (page - 1) * limit, limit
+}
+
+// NewMeta builds the response metadata for a page obtained from Parse.
+func NewMeta(offset, limit int, total int64) Meta {
+	return Meta{
+		Page:       offset/limit + 1,
+		PageSize:   limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxBodyBytes applies when bodyLimit.maxBytes is unset.
+const defaultMaxBodyBytes = 1 << 20
+
+// apiPrefixes are the prefixes the versioned routes are registered under.
+var apiPrefixes = []string{"/api/v1", "/api"}
+
+// newBodyLimit builds the body size middleware from config. Route overrides
+// are given relative to the API prefix and expanded for each prefix.
+func newBodyLimit() gin.HandlerFunc {
+	conf := config.AppConfig.BodyLimit
+	maxBytes := conf.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBodyBytes
+	}
+
+	overrides := make(map[string]int64, len(conf.Routes)*len(apiPrefixes))
+	for route, limit := range conf.Routes {
+		route = "/" + strings.Trim(strings.ToLower(route), "/")
+		for _, prefix := range apiPrefixes {
+			overrides[prefix+route] = limit
+		}
+	}
+	return middlewares.BodyLimit(maxBytes, overrides)
+}
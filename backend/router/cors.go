@@ -0,0 +1,102 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/gin-contrib/cors"
+)
+
+var (
+	defaultCORSOrigins = []string{"http://localhost:5173"}
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Origin", "Content-Type", "Authorization", "X-API-Key"}
+)
+
+// newCORSConfig builds the CORS settings from config.AppConfig.CORS. In
+// development it falls back to the local frontend dev server when nothing is
+// configured; in production the origins must be listed and may not be local.
+// An origin of "*" allows every origin, in which case credentials are
+// disabled.
+func newCORSConfig() (cors.Config, error) {
+	conf := config.AppConfig.CORS
+	production := config.IsProduction()
+
+	origins := conf.AllowedOrigins
+	if len(origins) == 0 {
+		if production {
+			return cors.Config{}, errors.New("cors.allowedOrigins must be set in production")
+		}
+		origins = defaultCORSOrigins
+	}
+	methods := conf.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := conf.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	maxAge := conf.MaxAge
+	if maxAge <= 0 {
+		maxAge = 12 * time.Hour
+	}
+
+	wildcard := false
+	for _, origin := range origins {
+		if origin == "*" {
+			wildcard = true
+			continue
+		}
+		if err := checkCORSOrigin(origin, production); err != nil {
+			return cors.Config{}, err
+		}
+	}
+
+	corsConf := cors.Config{
+		AllowMethods:  methods,
+		AllowHeaders:  headers,
+		ExposeHeaders: []string{"Content-Length"},
+		MaxAge:        maxAge,
+	}
+
+	if wildcard {
+		if len(origins) > 1 {
+			return cors.Config{}, errors.New(`cors: "*" cannot be combined with other allowed origins`)
+		}
+		if conf.AllowCredentials != nil && *conf.AllowCredentials {
+			return cors.Config{}, errors.New(`cors: credentials cannot be allowed with the "*" origin`)
+		}
+		corsConf.AllowAllOrigins = true
+	} else {
+		corsConf.AllowOrigins = origins
+		corsConf.AllowCredentials = conf.AllowCredentials == nil || *conf.AllowCredentials
+	}
+
+	if err := corsConf.Validate(); err != nil {
+		return cors.Config{}, err
+	}
+	return corsConf, nil
+}
+
+// checkCORSOrigin rejects origins a browser would never send, such as ones
+// with a path or a trailing slash, which would silently match nothing. In
+// production, loopback origins are rejected too.
+func checkCORSOrigin(origin string, production bool) error {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("cors: invalid origin %q, want scheme://host[:port]", origin)
+	}
+	if production {
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return fmt.Errorf("cors: local origin %q is not allowed in production", origin)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeNotFound answers requests that match no route in the API's JSON error
+// format instead of gin's plain-text body.
+func routeNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
+}
+
+// methodNotAllowed answers requests for a known path with a method it does not
+// support. gin has already set the Allow header; the methods are repeated in
+// the body for clients that don't look at headers.
+func methodNotAllowed(c *gin.Context) {
+	var allowed []string
+	if allow := c.Writer.Header().Get("Allow"); allow != "" {
+		allowed = strings.Split(allow, ", ")
+	}
+	c.JSON(http.StatusMethodNotAllowed, gin.H{
+		"error":   "method " + c.Request.Method + " is not allowed on this path",
+		"allowed": allowed,
+	})
+}
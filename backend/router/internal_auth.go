@@ -0,0 +1,22 @@
+package router
+
+import (
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultInternalMaxSkew applies when internal.maxClockSkew is unset.
+const defaultInternalMaxSkew = 5 * time.Minute
+
+// newInternalAuth builds the service-to-service auth middleware from config.
+func newInternalAuth() gin.HandlerFunc {
+	conf := config.AppConfig.Internal
+	maxSkew := conf.MaxClockSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultInternalMaxSkew
+	}
+	return middlewares.InternalAuth(conf.Secret, maxSkew)
+}
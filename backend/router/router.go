@@ -1,58 +1,197 @@
 package router
 
 import (
+	"net/http"
+	"os"
 	"time"
 
+	"github.com/JerryLinyx/FinGOAT/config"
 	"github.com/JerryLinyx/FinGOAT/controllers"
+	"github.com/JerryLinyx/FinGOAT/docs"
+	"github.com/JerryLinyx/FinGOAT/global"
 	"github.com/JerryLinyx/FinGOAT/middlewares"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 func InitRouter() *gin.Engine {
-	r := gin.Default()
-
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		// AllowOriginFunc: func(origin string) bool {
-		// 	return origin == "https://github.com"
-		// },
-		MaxAge: 12 * time.Hour,
-	}))
-
-	auth := r.Group("/api/auth")
+	r := gin.New()
+	// A trailing slash redirects to the route without it (307 for methods
+	// other than GET, so the method and body are kept), and a known path
+	// requested with the wrong method gets 405 with an Allow header
+	r.RedirectTrailingSlash = true
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(routeNotFound)
+	r.NoMethod(methodNotAllowed)
+	r.Use(middlewares.RequestID(), middlewares.Logger(), middlewares.Recovery())
+
+	// Right inside Recovery, so that requests rejected by the middleware
+	// below (413, 415, 503) are counted too
+	metricsConf := config.AppConfig.Metrics
+	if metricsConf.Enabled {
+		r.Use(middlewares.Metrics())
+	}
+
+	// Before anything that can abort the request, so that errors such as 413
+	// and 503 still carry CORS headers and browsers let the client read them
+	corsConf, err := newCORSConfig()
+	if err != nil {
+		global.Logger.Error("Invalid CORS configuration", "error", err)
+		os.Exit(1)
+	}
+	r.Use(cors.New(corsConf))
+
+	slowRequest := config.AppConfig.Log.SlowRequest
+	if slowRequest <= 0 {
+		slowRequest = 2 * time.Second
+	}
+	r.Use(middlewares.SlowRequests(slowRequest))
+
+	if config.AppConfig.App.CertFile != "" {
+		r.Use(middlewares.HSTS())
+	}
+
+	secureConf := config.AppConfig.SecureHeaders
+	if secureConf.Enabled {
+		r.Use(middlewares.SecureHeaders(secureConf.ContentSecurityPolicy, secureConf.ReferrerPolicy))
+	}
+
+	if compressionConf := config.AppConfig.Compression; compressionConf.Enabled {
+		r.Use(middlewares.Gzip(compressionConf.Level, compressionConf.MinSize))
+	}
+
+	r.Use(newBodyLimit())
+	r.Use(middlewares.RequireJSON())
+	r.Use(newTimeout())
+
+	// Unauthenticated; served from a dedicated listener instead when a metrics port is configured
+	if metricsConf.Enabled && metricsConf.Port == "" {
+		r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
+
+	// API docs: the spec is always available for tooling, the UI only when enabled
+	if config.AppConfig.Swagger.UIEnabled {
+		// The UI is an HTML page with scripts; the API's CSP would block it
+		r.GET("/swagger/*any", func(c *gin.Context) {
+			c.Writer.Header().Del("Content-Security-Policy")
+		}, ginSwagger.WrapHandler(swaggerFiles.Handler))
+	} else {
+		r.GET("/swagger/doc.json", func(c *gin.Context) {
+			c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(docs.SwaggerInfo.ReadDoc()))
+		})
+	}
+
+	// Service-to-service endpoints; authenticated by shared secret, not user JWTs
+	internal := r.Group("/internal", newInternalAuth())
+	internal.POST("/trading/callback", controllers.TradingServiceCallback)
+
+	// API versions are registered side by side. The unversioned /api prefix
+	// is a deprecated alias of v1 kept for existing clients.
+	registerV1Routes(r.Group("/api/v1", middlewares.APIVersion("v1")))
+	registerV1Routes(r.Group("/api", middlewares.APIVersion("v1"), middlewares.Deprecated("/api/v1")))
+
+	return r
+}
+
+// registerV1Routes registers the v1 API on the given group.
+func registerV1Routes(v1 *gin.RouterGroup) {
+	auth := v1.Group("/auth")
 	{
 		auth.POST("/login", controllers.Login)
 		auth.POST("/register", controllers.Register)
+		auth.POST("/verify-email", controllers.VerifyEmail)
+		auth.GET("/oauth/google", controllers.GoogleLogin)
+		auth.GET("/oauth/google/callback", controllers.GoogleCallback)
+		auth.POST("/change-password", middlewares.AuthMiddleware(), middlewares.RequireTokenAuth(), middlewares.RequireVerifiedEmail(), controllers.ChangePassword)
+		auth.GET("/me", middlewares.AuthMiddleware(), controllers.GetProfile)
+		auth.PUT("/me", middlewares.AuthMiddleware(), controllers.UpdateProfile)
+
+		// Managing keys needs a login session, not another key
+		apiKeys := auth.Group("/api-keys", middlewares.AuthMiddleware(), middlewares.RequireTokenAuth())
+		apiKeys.GET("", controllers.ListAPIKeys)
+		apiKeys.POST("", controllers.CreateAPIKey)
+		apiKeys.DELETE("/:id", controllers.RevokeAPIKey)
 	}
 
-	api := r.Group("/api")
+	api := v1.Group("")
 	api.GET("/exchangeRates", controllers.GetExchangeRates)
+	api.GET("/exchangeRates/latest", controllers.GetLatestExchangeRate)
+	api.GET("/exchangeRates/ws", controllers.StreamExchangeRates)
+	api.GET("/feed.atom", controllers.GetAtomFeed)
+	api.GET("/feed.json", controllers.GetJSONFeed)
 	api.Use(middlewares.AuthMiddleware())
 	{
+		admin := middlewares.RequireRole(middlewares.RoleAdmin)
+
 		api.POST("/exchangeRates", controllers.CreateExchangeRate)
+		api.POST("/exchangeRates/batch", admin, controllers.CreateExchangeRatesBatch)
 
 		api.GET("/articles", controllers.GetArticles)
 		api.GET("/articles/:id", controllers.GetArticlesByID)
 		api.POST("/articles", controllers.CreateArticle)
 
+		api.POST("/articles/import", admin, controllers.ImportArticles)
+		api.DELETE("/articles/:id", admin, controllers.DeleteArticle)
+		api.GET("/articles/trash", admin, controllers.GetDeletedArticles)
+		api.POST("/articles/:id/restore", admin, controllers.RestoreArticle)
+
+		adminAPI := api.Group("/admin", admin)
+		adminAPI.GET("/users", controllers.ListUsers)
+		adminAPI.PATCH("/users/:id", controllers.UpdateUser)
+		adminAPI.POST("/users/:id/deactivate", controllers.DeactivateUser)
+		adminAPI.POST("/users/:id/reactivate", controllers.ReactivateUser)
+
+		api.POST("/articles/:id/tags", controllers.AddArticleTags)
+		api.DELETE("/articles/:id/tags/:tag", controllers.RemoveArticleTag)
+		api.GET("/tags", controllers.ListTags)
+
+		api.POST("/articles/:id/bookmark", controllers.BookmarkArticle)
+		api.DELETE("/articles/:id/bookmark", controllers.RemoveBookmark)
+		api.GET("/bookmarks", controllers.ListBookmarks)
+
+		api.POST("/articles/:id/comments", controllers.CreateComment)
+		api.GET("/articles/:id/comments", controllers.ListComments)
+		api.DELETE("/comments/:id", controllers.DeleteComment)
+
 		api.POST("/articles/:id/like", controllers.LikeArticle)
 		api.GET("/articles/:id/like", controllers.GetArticleLikes)
 
 		// Trading analysis routes
 		trading := api.Group("/trading")
 		{
-			trading.POST("/analyze", controllers.RequestAnalysis)
+			trading.POST("/analyze", middlewares.RequireVerifiedEmail(), controllers.RequestAnalysis)
+			trading.POST("/analyze/batch", middlewares.RequireVerifiedEmail(), controllers.RequestBatchAnalysis)
 			trading.GET("/analysis/:task_id", controllers.GetAnalysisResult)
-			trading.GET("/analyses", controllers.ListUserAnalyses)
+			trading.POST("/analysis/:task_id/retry", middlewares.RequireVerifiedEmail(), controllers.RetryAnalysis)
+			trading.GET("/analyses", middlewares.Negotiate(middlewares.MediaJSON, middlewares.MediaCSV), controllers.ListUserAnalyses)
+			trading.GET("/analyses/export", middlewares.Negotiate(middlewares.MediaCSV, middlewares.MediaJSON), controllers.ExportAnalyses)
+			trading.DELETE("/analyses", controllers.DeleteAnalyses)
+			trading.DELETE("/analysis/:task_id", controllers.DeleteAnalysis)
+			trading.GET("/compare", controllers.CompareAnalyses)
 			trading.GET("/stats", controllers.GetAnalysisStats)
+			trading.GET("/stats/global", middlewares.RequireRole(middlewares.RoleAdmin), controllers.GetGlobalAnalysisStats)
 			trading.GET("/health", controllers.CheckServiceHealth)
+			trading.GET("/providers", controllers.ListLLMProviders)
+			trading.GET("/ticker/:symbol", controllers.GetTickerInfo)
+			trading.GET("/ticker/:symbol/analyses", controllers.ListTickerAnalyses)
+
+			trading.GET("/presets", controllers.ListPresets)
+			trading.POST("/presets", controllers.CreatePreset)
+			trading.PUT("/presets/:id", controllers.UpdatePreset)
+			trading.DELETE("/presets/:id", controllers.DeletePreset)
+
+			trading.GET("/schedules", controllers.ListSchedules)
+			trading.POST("/schedules", middlewares.RequireVerifiedEmail(), controllers.CreateSchedule)
+			trading.PUT("/schedules/:id", middlewares.RequireVerifiedEmail(), controllers.UpdateSchedule)
+			trading.DELETE("/schedules/:id", controllers.DeleteSchedule)
+
+			trading.GET("/webhook", controllers.GetWebhook)
+			trading.PUT("/webhook", controllers.UpdateWebhook)
+			trading.DELETE("/webhook", controllers.DeleteWebhook)
+			trading.GET("/webhook/deliveries", controllers.ListWebhookDeliveries)
 		}
 	}
-
-	return r
 }
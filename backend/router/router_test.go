@@ -0,0 +1,113 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/metrics"
+	"github.com/JerryLinyx/FinGOAT/testutil"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testOrigin = "http://localhost:5173"
+
+func TestCORSHeadersOnAbortedRequests(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.BodyLimit.MaxBytes = 16
+	r := InitRouter()
+
+	w := testutil.Do(r, http.MethodPost, "/api/v1/auth/login", `{"username":"`+strings.Repeat("x", 64)+`"}`,
+		"Origin", testOrigin)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status %d, want 413", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != testOrigin {
+		t.Fatalf("Access-Control-Allow-Origin = %q on a 413, want %q", got, testOrigin)
+	}
+
+	// Preflights are answered before the JSON content type is required
+	w = testutil.Do(r, http.MethodOptions, "/api/v1/auth/login", "",
+		"Origin", testOrigin, "Access-Control-Request-Method", http.MethodPost)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != testOrigin {
+		t.Fatalf("preflight: status %d, Access-Control-Allow-Origin %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestMetricsCountRejectedRequests(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.Metrics.Enabled = true
+	conf.BodyLimit.MaxBytes = 16
+	r := InitRouter()
+
+	for _, tt := range []struct {
+		body, contentType, status string
+	}{
+		{`{"username":"` + strings.Repeat("x", 64) + `"}`, "application/json", "413"},
+		{"username=x", "application/x-www-form-urlencoded", "415"},
+	} {
+		counter := metrics.HTTPRequestsTotal.WithLabelValues(http.MethodPost, "/api/v1/auth/login", tt.status)
+		before := promtest.ToFloat64(counter)
+		w := testutil.Do(r, http.MethodPost, "/api/v1/auth/login", tt.body, "Content-Type", tt.contentType)
+		if strconv.Itoa(w.Code) != tt.status {
+			t.Fatalf("status %d, want %s", w.Code, tt.status)
+		}
+		if n := promtest.ToFloat64(counter) - before; n != 1 {
+			t.Fatalf("%s counted %v times, want once", tt.status, n)
+		}
+	}
+}
+
+func TestTrailingSlashRedirects(t *testing.T) {
+	testutil.Config(t)
+	r := InitRouter()
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/api/v1/feed.atom/", http.StatusMovedPermanently},
+		// Other methods keep their method and body through the redirect
+		{http.MethodPost, "/api/v1/auth/login/", http.StatusTemporaryRedirect},
+	}
+	for _, tt := range tests {
+		w := testutil.Do(r, tt.method, tt.path, "{}", "Content-Type", "application/json")
+		if w.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, w.Code, tt.status)
+		}
+		if loc, want := w.Header().Get("Location"), strings.TrimSuffix(tt.path, "/"); loc != want {
+			t.Errorf("%s %s: Location %q, want %q", tt.method, tt.path, loc, want)
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	testutil.Config(t)
+	r := InitRouter()
+
+	w := testutil.Do(r, http.MethodDelete, "/api/v1/feed.atom", "")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status %d, want 405", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); !strings.Contains(allow, http.MethodGet) {
+		t.Fatalf("Allow = %q, want it to list GET", allow)
+	}
+	var resp struct {
+		Allowed []string `json:"allowed"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(resp.Allowed, http.MethodGet) {
+		t.Fatalf("allowed = %v, want it to list GET", resp.Allowed)
+	}
+
+	// An unknown path is still a 404
+	if w := testutil.Do(r, http.MethodDelete, "/api/v1/no-such-route", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown path: status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRequestTimeout applies when request_timeout.default is unset.
+const defaultRequestTimeout = 30 * time.Second
+
+// newTimeout builds the request deadline middleware from config. Like body
+// limits, route overrides are relative to the API prefix.
+func newTimeout() gin.HandlerFunc {
+	conf := config.AppConfig.RequestTimeout
+	timeout := conf.Default
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	overrides := make(map[string]time.Duration, len(conf.Routes)*len(apiPrefixes))
+	for route, d := range conf.Routes {
+		route = "/" + strings.Trim(strings.ToLower(route), "/")
+		for _, prefix := range apiPrefixes {
+			overrides[prefix+route] = d
+		}
+	}
+	return middlewares.Timeout(timeout, overrides)
+}
@@ -0,0 +1,134 @@
+// Package testutil sets up the globals handlers depend on, for use in tests: an
+// empty configuration, a throwaway SQLite database with every table migrated,
+// and an in-memory Redis. Each helper but Redis restores the previous value
+// when the test ends.
+//
+// SQLite stands in for Postgres so the tests run without external services;
+// queries written against Postgres-only features cannot be tested this way.
+package testutil
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/validators"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	if err := validators.Register(); err != nil {
+		panic(err)
+	}
+}
+
+// Config installs an empty configuration, so every setting has its default,
+// and silences the logger. Tests adjust the returned config as needed.
+func Config(t testing.TB) *config.Config {
+	t.Helper()
+	prevConf, prevLogger := config.AppConfig, global.Logger
+	t.Cleanup(func() {
+		config.AppConfig, global.Logger = prevConf, prevLogger
+	})
+	config.AppConfig = &config.Config{}
+	global.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return config.AppConfig
+}
+
+// DB opens a fresh SQLite database in a temporary directory, migrates the
+// application's tables and installs it as global.DB.
+func DB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Discard,
+		// Report constraint violations as gorm.ErrDuplicatedKey, since the
+		// Postgres error codes the handlers look for don't apply here
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	if err := db.AutoMigrate(config.Models()...); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	prev := global.DB
+	t.Cleanup(func() {
+		global.DB = prev
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	global.DB = db
+	return db
+}
+
+// CountQueries counts the SELECTs run against table from now until the test
+// ends. Each query is held for delay first, which gives concurrent callers time
+// to pile up behind it.
+func CountQueries(t testing.TB, db *gorm.DB, table string, delay time.Duration) *atomic.Int64 {
+	t.Helper()
+	var count atomic.Int64
+	name := "testutil:count_" + table
+	err := db.Callback().Query().Before("gorm:query").Register(name, func(tx *gorm.DB) {
+		if tx.Statement.Table == table {
+			count.Add(1)
+			time.Sleep(delay)
+		}
+	})
+	if err != nil {
+		t.Fatalf("register query counter: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Callback().Query().Remove(name) })
+	return &count
+}
+
+// Redis starts an in-memory Redis server and installs a client for it as
+// global.RedisDB. Close the returned server to simulate an outage.
+//
+// Unlike the other helpers it leaves the client installed, closed, when the
+// test ends: handlers invalidate caches in the background, and that work may
+// still be running. It then fails like any Redis error instead of panicking.
+func Redis(t testing.TB) *miniredis.Miniredis {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	global.RedisDB = client
+	return srv
+}
+
+// Do serves a request with the given method, path and JSON body (none when
+// empty) on h and returns the recorded response. Headers are given as
+// alternating names and values.
+func Do(h http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
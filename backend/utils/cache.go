@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis is a best-effort cache: these helpers log failures instead of
+// returning them, so callers fall back to the database when Redis is down.
+//
+// After a failure, reads and writes skip Redis for cacheCooldown so that
+// during an outage requests go straight to the database instead of each
+// waiting for the client's dial timeouts and retries. Deletes are always
+// attempted so no stale entry outlives an invalidation.
+const cacheCooldown = 5 * time.Second
+
+// cacheSkipUntil is the UnixNano time until which Redis is bypassed.
+var cacheSkipUntil atomic.Int64
+
+func cacheAvailable() bool {
+	return time.Now().UnixNano() >= cacheSkipUntil.Load()
+}
+
+func cacheFailed() {
+	cacheSkipUntil.Store(time.Now().Add(cacheCooldown).UnixNano())
+}
+
+// CacheGet returns the cached value for key. A Redis error is logged and
+// reported as a miss.
+func CacheGet(ctx context.Context, key string) (string, bool) {
+	if !cacheAvailable() {
+		return "", false
+	}
+	value, err := global.RedisDB.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", false
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			cacheFailed()
+		}
+		global.Logger.Warn("Cache read failed, falling back to database", "key", key, "error", err)
+		return "", false
+	}
+	return value, true
+}
+
+// CacheSet stores value under key for ttl, logging any failure.
+func CacheSet(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if !cacheAvailable() {
+		return
+	}
+	if err := global.RedisDB.Set(ctx, key, value, ttl).Err(); err != nil {
+		if ctx.Err() == nil {
+			cacheFailed()
+		}
+		global.Logger.Warn("Cache write failed", "key", key, "error", err)
+	}
+}
+
+// CacheSetNX stores value under key unless the key already holds a value, and
+// returns the value the key holds afterwards, so that concurrent callers agree
+// on one. When Redis is unavailable value itself is returned.
+func CacheSetNX(ctx context.Context, key, value string, ttl time.Duration) string {
+	if !cacheAvailable() {
+		return value
+	}
+	if err := global.RedisDB.SetNX(ctx, key, value, ttl).Err(); err != nil {
+		if ctx.Err() == nil {
+			cacheFailed()
+		}
+		global.Logger.Warn("Cache write failed", "key", key, "error", err)
+		return value
+	}
+	if current, hit := CacheGet(ctx, key); hit {
+		return current
+	}
+	return value
+}
+
+// CacheDel removes keys, logging any failure.
+func CacheDel(ctx context.Context, keys ...string) {
+	if err := global.RedisDB.Del(ctx, keys...).Err(); err != nil {
+		global.Logger.Warn("Cache invalidation failed", "keys", keys, "error", err)
+	}
+}
+
+// CacheIncr increments the counter at key, logging any failure. Like deletes,
+// increments are always attempted since they are used for invalidation.
+func CacheIncr(ctx context.Context, key string) {
+	if err := global.RedisDB.Incr(ctx, key).Err(); err != nil {
+		global.Logger.Warn("Cache invalidation failed", "key", key, "error", err)
+	}
+}
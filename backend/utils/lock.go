@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/go-redis/redis/v8"
+)
+
+// releaseLockScript deletes the lock only if it still holds our token, so a
+// holder whose lock expired cannot release one taken over by someone else.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Lock is a lock held in Redis, shared by every backend replica.
+type Lock struct {
+	key   string
+	token string
+}
+
+// AcquireLock tries to take the lock named key for at most ttl. It returns nil
+// and no error when another holder has it. The TTL frees the lock if the holder
+// crashes before calling Release.
+func AcquireLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	lock := &Lock{key: key, token: hex.EncodeToString(b)}
+
+	ok, err := global.RedisDB.SetNX(ctx, key, lock.token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	return lock, nil
+}
+
+// Release gives up the lock, logging any failure; the TTL frees it regardless.
+func (l *Lock) Release(ctx context.Context) {
+	if err := releaseLockScript.Run(ctx, global.RedisDB, []string{l.key}, l.token).Err(); err != nil {
+		global.Logger.Warn("Failed to release lock", "key", l.key, "error", err)
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+)
+
+// ErrMailDisabled is returned by SendMail when no SMTP host is configured.
+var ErrMailDisabled = errors.New("email sending is not configured")
+
+// SendMail sends a plain-text email through the configured SMTP server.
+func SendMail(to, subject, body string) error {
+	conf := config.AppConfig.Email
+	if conf.SMTPHost == "" {
+		return ErrMailDisabled
+	}
+
+	port := conf.SMTPPort
+	if port == "" {
+		port = "587"
+	}
+
+	var auth smtp.Auth
+	if conf.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", conf.SMTPUsername, conf.SMTPPassword, conf.SMTPHost)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + conf.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	if err := smtp.SendMail(net.JoinHostPort(conf.SMTPHost, port), auth, conf.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+)
+
+const defaultMinPasswordLength = 8
+
+// PasswordPolicyError lists every requirement a password failed to meet.
+type PasswordPolicyError struct {
+	Unmet []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet requirements: " + strings.Join(e.Unmet, "; ")
+}
+
+// ValidatePassword checks a password against the configured password policy.
+// It returns a *PasswordPolicyError when one or more requirements are not met.
+func ValidatePassword(password string) error {
+	policy := config.AppConfig.Password
+	minLength := policy.MinLength
+	if minLength <= 0 {
+		minLength = defaultMinPasswordLength
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var unmet []string
+	if len([]rune(password)) < minLength {
+		unmet = append(unmet, fmt.Sprintf("at least %d characters", minLength))
+	}
+	if policy.RequireUpper && !hasUpper {
+		unmet = append(unmet, "at least one uppercase letter")
+	}
+	if policy.RequireLower && !hasLower {
+		unmet = append(unmet, "at least one lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		unmet = append(unmet, "at least one digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		unmet = append(unmet, "at least one symbol")
+	}
+
+	if len(unmet) > 0 {
+		return &PasswordPolicyError{Unmet: unmet}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/JerryLinyx/FinGOAT/testutil"
+)
+
+func TestValidatePassword(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.Password.RequireUpper = true
+	conf.Password.RequireLower = true
+	conf.Password.RequireDigit = true
+	conf.Password.RequireSymbol = true
+
+	tests := []struct {
+		password string
+		unmet    []string
+	}{
+		{"Secr3t!pass", nil},
+		{"Ünïcödé9!", nil}, // length counts characters, not bytes
+		{"Sh0rt!", []string{"at least 8 characters"}},
+		{"alllower1!", []string{"at least one uppercase letter"}},
+		{"ALLUPPER1!", []string{"at least one lowercase letter"}},
+		{"NoDigits!!", []string{"at least one digit"}},
+		{"NoSymbol12", []string{"at least one symbol"}},
+		{"", []string{
+			"at least 8 characters",
+			"at least one uppercase letter",
+			"at least one lowercase letter",
+			"at least one digit",
+			"at least one symbol",
+		}},
+	}
+	for _, tt := range tests {
+		err := ValidatePassword(tt.password)
+		if tt.unmet == nil {
+			if err != nil {
+				t.Errorf("ValidatePassword(%q) = %v, want nil", tt.password, err)
+			}
+			continue
+		}
+		var policyErr *PasswordPolicyError
+		if !errors.As(err, &policyErr) {
+			t.Errorf("ValidatePassword(%q) = %v, want a *PasswordPolicyError", tt.password, err)
+			continue
+		}
+		if !slices.Equal(policyErr.Unmet, tt.unmet) {
+			t.Errorf("ValidatePassword(%q) unmet = %q, want %q", tt.password, policyErr.Unmet, tt.unmet)
+		}
+	}
+}
+
+func TestValidatePasswordDefaults(t *testing.T) {
+	testutil.Config(t)
+
+	// Only the minimum length applies unless the policy asks for more
+	if err := ValidatePassword("password"); err != nil {
+		t.Errorf("ValidatePassword(8 lowercase letters) = %v, want nil", err)
+	}
+	if err := ValidatePassword("passwor"); err == nil {
+		t.Error("ValidatePassword(7 characters) = nil, want an error")
+	}
+}
+
+func TestValidatePasswordMinLength(t *testing.T) {
+	conf := testutil.Config(t)
+	conf.Password.MinLength = 12
+
+	if err := ValidatePassword("elevenchars"); err == nil {
+		t.Error("ValidatePassword(11 characters) = nil with minLength 12, want an error")
+	}
+	if err := ValidatePassword("twelve chars"); err != nil {
+		t.Errorf("ValidatePassword(12 characters) = %v, want nil", err)
+	}
+}
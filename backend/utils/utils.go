@@ -2,12 +2,19 @@ package utils
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/JerryLinyx/FinGOAT/config"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrTokenExpired is returned by ParseJWT for a well-formed, correctly signed
+// token whose exp has passed, so callers can tell clients to refresh.
+var ErrTokenExpired = errors.New("token expired")
+
 func HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -16,41 +23,111 @@ func HashPassword(password string) (string, error) {
 	return string(hashedPassword), nil
 }
 
-func GenerateJWT(username string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username": username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+// Claims are the JWT claims issued at login. Tokens issued before UserID and
+// Role were added carry only the username.
+type Claims struct {
+	Username string `json:"username"`
+	UserID   uint   `json:"uid,omitempty"`
+	Role     string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func GenerateJWT(userID uint, username, role string) (string, error) {
+	conf := config.AppConfig.Auth
+	ttl := conf.AccessTTL
+	if ttl <= 0 {
+		ttl = time.Hour * 24
+	}
+	now := time.Now()
+
+	registered := jwt.RegisteredClaims{
+		Issuer:    conf.Issuer,
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+	if conf.Audience != "" {
+		registered.Audience = jwt.ClaimStrings{conf.Audience}
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Username:         username,
+		UserID:           userID,
+		Role:             role,
+		RegisteredClaims: registered,
 	})
-	tokenString, err := token.SignedString([]byte("JWT_SECRET"))
+	key := currentSigningKey()
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	tokenString, err := token.SignedString([]byte(key.Secret))
 	return "Bearer " + tokenString, err
 }
 
+// defaultJWTSecret signs tokens when no key is configured. Tokens issued
+// before keys were configurable were all signed with it.
+const defaultJWTSecret = "JWT_SECRET"
+
+func currentSigningKey() config.JWTKey {
+	key := config.AppConfig.Auth.SigningKey
+	if key.Secret == "" {
+		return config.JWTKey{ID: key.ID, Secret: defaultJWTSecret}
+	}
+	return key
+}
+
+// verificationKey returns the secret of the current or a previous signing key
+// whose ID matches kid.
+func verificationKey(kid string) ([]byte, error) {
+	if key := currentSigningKey(); key.ID == kid {
+		return []byte(key.Secret), nil
+	}
+	for _, key := range config.AppConfig.Auth.PreviousKeys {
+		if key.ID == kid && key.Secret != "" {
+			return []byte(key.Secret), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
 func CheckPassword(password string, hashedPassword string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
 
-func ParseJWT(tokenString string) (string, error) {
+func ParseJWT(tokenString string) (*Claims, error) {
 	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
 		tokenString = tokenString[7:]
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	// iat is checked so that tokens claiming to be issued in the future are
+	// rejected; iss and aud only when configured
+	conf := config.AppConfig.Auth
+	opts := []jwt.ParserOption{jwt.WithIssuedAt(), jwt.WithExpirationRequired()}
+	if conf.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(conf.Issuer))
+	}
+	if conf.Audience != "" {
+		opts = append(opts, jwt.WithAudience(conf.Audience))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte("JWT_SECRET"), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		return verificationKey(kid)
+	}, opts...)
 
 	if err != nil {
-		return "", err
-	}
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		username, ok := claims["username"].(string)
-		if !ok {
-			return "", errors.New("username claim is not a string")
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
 		}
-		return username, nil
+		return nil, err
+	}
+	if claims.Username == "" {
+		return nil, errors.New("token has no username claim")
 	}
-	return "", errors.New("invalid token claims")
+	return claims, nil
 }
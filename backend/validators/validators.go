@@ -0,0 +1,90 @@
+package validators
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// DateLayout is the calendar date format accepted by the isodate tag.
+const DateLayout = "2006-01-02"
+
+// Register adds the custom binding tags to gin's validator. Call it once at
+// startup, before any request is bound.
+//
+//	isodate   a string holding a real calendar date in YYYY-MM-DD form
+//	currency  a three-letter currency code such as USD, in either case
+func Register() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+	if err := v.RegisterValidation("isodate", isISODate); err != nil {
+		return err
+	}
+	return v.RegisterValidation("currency", isCurrencyCode)
+}
+
+func isISODate(fl validator.FieldLevel) bool {
+	// time.Parse also rejects impossible days such as Feb 29 in a common year
+	_, err := time.Parse(DateLayout, fl.Field().String())
+	return err == nil
+}
+
+func isCurrencyCode(fl validator.FieldLevel) bool {
+	return IsCurrencyCode(fl.Field().String())
+}
+
+// IsCurrencyCode reports whether code is three ASCII letters, in either case.
+func IsCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrorMessage turns a binding error into a message for the client. Failures
+// of the custom tags and empty bodies get a readable explanation; anything else
+// is returned as is.
+func ErrorMessage(err error) string {
+	if errors.Is(err, io.EOF) {
+		return "request body is required"
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err.Error()
+	}
+
+	msgs := make([]string, 0, len(verrs))
+	for _, fe := range verrs {
+		switch fe.Tag() {
+		case "isodate":
+			msgs = append(msgs, fmt.Sprintf("%s must be a valid date in YYYY-MM-DD format, got %q", fieldName(fe), fe.Value()))
+		case "currency":
+			msgs = append(msgs, fmt.Sprintf("%s must be a three-letter currency code, got %q", fieldName(fe), fe.Value()))
+		default:
+			msgs = append(msgs, fe.Error())
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// fieldName returns the field's path without the top-level struct name,
+// e.g. "Items[2].Date".
+func fieldName(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if _, rest, ok := strings.Cut(ns, "."); ok {
+		return rest
+	}
+	return fe.Field()
+}
@@ -0,0 +1,53 @@
+package validators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestISODate(t *testing.T) {
+	v := validator.New()
+	if err := v.RegisterValidation("isodate", isISODate); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		date  string
+		valid bool
+	}{
+		{"2024-02-29", true}, // leap year
+		{"2024-12-31", true},
+		{"2023-02-29", false}, // common year
+		{"2024-13-01", false},
+		{"2024-00-10", false},
+		{"2024-04-31", false},
+		{"2024-1-5", false},
+		{"20240105", false},
+		{"2024-01-05T00:00:00Z", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		err := v.Var(tt.date, "isodate")
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("isodate(%q) valid = %v, want %v", tt.date, valid, tt.valid)
+		}
+	}
+}
+
+func TestErrorMessageNamesInvalidDate(t *testing.T) {
+	v := validator.New()
+	if err := v.RegisterValidation("isodate", isISODate); err != nil {
+		t.Fatal(err)
+	}
+	var req struct {
+		Date string `json:"date" validate:"isodate"`
+	}
+	req.Date = "2023-02-29"
+
+	msg := ErrorMessage(v.Struct(req))
+	if !strings.Contains(msg, "YYYY-MM-DD") || !strings.Contains(msg, `"2023-02-29"`) {
+		t.Fatalf("ErrorMessage = %q, want the format and the rejected value", msg)
+	}
+}
@@ -0,0 +1,53 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/controllers"
+	"github.com/JerryLinyx/FinGOAT/global"
+)
+
+// RunAnalysisScheduler submits scheduled analyses as they fall due until ctx
+// is cancelled. Schedules that fail, e.g. while the trading service is down,
+// are retried on the next tick. With several replicas, one runs each tick.
+func RunAnalysisScheduler(ctx context.Context) {
+	conf := config.AppConfig.Schedule
+	if !conf.Enabled {
+		return
+	}
+
+	interval := conf.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	global.Logger.Info("Analysis scheduler started", "interval", interval.String())
+	for {
+		select {
+		case <-ctx.Done():
+			global.Logger.Info("Analysis scheduler stopped")
+			return
+		case <-ticker.C:
+			_ = runExclusive(ctx, "analysis_schedule", interval, func() error { return runScheduleCycle(ctx) })
+		}
+	}
+}
+
+func runScheduleCycle(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			global.Logger.Error("Analysis scheduler cycle panicked", "panic", r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	if err = controllers.RunDueSchedules(ctx); err != nil && ctx.Err() == nil {
+		global.Logger.Warn("Analysis scheduler cycle failed", "error", err)
+	}
+	return err
+}
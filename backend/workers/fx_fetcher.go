@@ -0,0 +1,152 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/controllers"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+)
+
+var fxHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// fxProviderResponse matches the "latest" payload shared by exchangerate.host
+// and the ECB-backed frankfurter.app API.
+type fxProviderResponse struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// RunFXFetcher periodically pulls exchange rates from the configured provider
+// and upserts them until ctx is cancelled. Failures are logged and retried on
+// the next tick. With several replicas, one fetches each interval.
+func RunFXFetcher(ctx context.Context) {
+	conf := config.AppConfig.FX
+	if !conf.Enabled {
+		return
+	}
+
+	interval := conf.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	global.Logger.Info("FX fetcher started", "interval", interval.String())
+	for {
+		err := runExclusive(ctx, "fx_fetch", interval, func() error { return runFXFetch(ctx) })
+		if err != nil {
+			global.Logger.Warn("FX fetch failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			global.Logger.Info("FX fetcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runFXFetch performs a single fetch cycle, converting panics into errors so a
+// bad payload cannot take the scheduler down.
+func runFXFetch(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	rates, err := fetchFXRates(ctx)
+	if err != nil {
+		return err
+	}
+
+	written := 0
+	for i := range rates {
+		changed, err := controllers.UpsertDailyExchangeRate(ctx, &rates[i])
+		if err != nil {
+			global.Logger.Warn("FX fetch: failed to save rate",
+				"from", rates[i].FromCurrency, "to", rates[i].ToCurrency, "error", err)
+			continue
+		}
+		if changed {
+			written++
+		}
+	}
+	if written > 0 {
+		controllers.InvalidateExchangeRatesCache(ctx)
+	}
+	global.Logger.Info("FX fetch completed", "updated", written, "fetched", len(rates))
+	return nil
+}
+
+func fetchFXRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	conf := config.AppConfig.FX
+	base := strings.ToUpper(conf.BaseCurrency)
+	if base == "" {
+		base = "USD"
+	}
+
+	endpoint, err := url.Parse(conf.ProviderURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider url: %w", err)
+	}
+	query := endpoint.Query()
+	query.Set("base", base)
+	if len(conf.TargetCurrencies) > 0 {
+		query.Set("symbols", strings.ToUpper(strings.Join(conf.TargetCurrencies, ",")))
+	}
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fxHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("provider returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload fxProviderResponse
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse provider response: %w", err)
+	}
+	if len(payload.Rates) == 0 {
+		return nil, fmt.Errorf("provider returned no rates")
+	}
+
+	now := time.Now().UTC()
+	rates := make([]models.ExchangeRate, 0, len(payload.Rates))
+	for currency, rate := range payload.Rates {
+		if currency == base {
+			continue
+		}
+		rates = append(rates, models.ExchangeRate{
+			FromCurrency: base,
+			ToCurrency:   currency,
+			Rate:         rate,
+			Date:         now,
+		})
+	}
+	return rates, nil
+}
@@ -0,0 +1,40 @@
+package workers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/utils"
+)
+
+const jobLockKeyPrefix = "lock:job:"
+
+// runExclusive runs job unless another replica already ran it in the current
+// cycle, the interval-aligned window containing now. The lock is per job and
+// cycle: a successful run keeps it until the cycle ends, so replicas whose
+// tickers fire later in the same window skip the job, while a failed run
+// releases it so another replica can retry. If Redis is unavailable the job
+// runs anyway, as a single-replica deployment would.
+func runExclusive(ctx context.Context, name string, interval time.Duration, job func() error) error {
+	now := time.Now()
+	cycle := now.Truncate(interval)
+	key := jobLockKeyPrefix + name + ":" + strconv.FormatInt(cycle.Unix(), 10)
+
+	lock, err := utils.AcquireLock(ctx, key, cycle.Add(interval).Sub(now))
+	if err != nil {
+		global.Logger.Warn("Failed to acquire job lock; running unlocked", "job", name, "error", err)
+		return job()
+	}
+	if lock == nil {
+		global.Logger.Debug("Job already handled by another replica", "job", name)
+		return nil
+	}
+
+	if err := job(); err != nil {
+		lock.Release(context.WithoutCancel(ctx))
+		return err
+	}
+	return nil
+}
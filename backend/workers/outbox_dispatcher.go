@@ -0,0 +1,41 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/controllers"
+	"github.com/JerryLinyx/FinGOAT/global"
+)
+
+// RunOutboxDispatcher delivers queued webhook events until ctx is cancelled.
+func RunOutboxDispatcher(ctx context.Context) {
+	interval := config.AppConfig.Webhook.DispatchInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	global.Logger.Info("Outbox dispatcher started", "interval", interval.String())
+	for {
+		select {
+		case <-ctx.Done():
+			global.Logger.Info("Outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+			runDispatchCycle(ctx)
+		}
+	}
+}
+
+func runDispatchCycle(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			global.Logger.Error("Outbox dispatcher cycle panicked", "panic", r)
+		}
+	}()
+
+	controllers.DeliverPendingWebhooks(ctx)
+}
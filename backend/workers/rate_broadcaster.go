@@ -0,0 +1,63 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/controllers"
+	"github.com/JerryLinyx/FinGOAT/global"
+	"github.com/JerryLinyx/FinGOAT/models"
+)
+
+// RunRateBroadcaster relays exchange rate updates published by any replica to
+// the websocket subscribers connected to this one, until ctx is cancelled.
+// The subscription is re-established if Redis drops it.
+func RunRateBroadcaster(ctx context.Context) {
+	global.Logger.Info("Rate broadcaster started", "channel", controllers.ExchangeRateUpdatesChannel)
+	for {
+		relayRateUpdates(ctx)
+		select {
+		case <-ctx.Done():
+			controllers.CloseRateSubscribers()
+			global.Logger.Info("Rate broadcaster stopped")
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func relayRateUpdates(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			global.Logger.Error("Rate broadcaster panicked", "panic", r)
+		}
+	}()
+
+	pubsub := global.RedisDB.Subscribe(ctx, controllers.ExchangeRateUpdatesChannel)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() == nil {
+			global.Logger.Warn("Failed to subscribe to exchange rate updates", "error", err)
+		}
+		return
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var rate models.ExchangeRate
+			if err := json.Unmarshal([]byte(msg.Payload), &rate); err != nil {
+				global.Logger.Warn("Discarding malformed exchange rate update", "error", err)
+				continue
+			}
+			controllers.BroadcastExchangeRate(rate)
+		}
+	}
+}
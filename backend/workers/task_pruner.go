@@ -0,0 +1,63 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/config"
+	"github.com/JerryLinyx/FinGOAT/controllers"
+	"github.com/JerryLinyx/FinGOAT/global"
+)
+
+// RunTaskPruner removes finished analysis tasks past the retention window on
+// each tick until ctx is cancelled. With several replicas, one prunes each
+// interval.
+func RunTaskPruner(ctx context.Context) {
+	conf := config.AppConfig.Retention
+	if !conf.Enabled {
+		return
+	}
+
+	interval := conf.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	maxAge := conf.MaxAge
+	if maxAge <= 0 {
+		maxAge = 90 * 24 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	global.Logger.Info("Task pruner started", "interval", interval.String(), "maxAge", maxAge.String(),
+		"keepPerUser", conf.KeepPerUser, "hardDelete", conf.HardDelete)
+	for {
+		err := runExclusive(ctx, "task_prune", interval, func() error {
+			return runPruneCycle(ctx, maxAge, conf.KeepPerUser, conf.HardDelete)
+		})
+		if err != nil && ctx.Err() == nil {
+			global.Logger.Warn("Task pruning failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			global.Logger.Info("Task pruner stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func runPruneCycle(ctx context.Context, maxAge time.Duration, keepPerUser int, hard bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	tasks, decisions, err := controllers.PruneAnalysisTasks(ctx, time.Now().Add(-maxAge), keepPerUser, hard)
+	// Batches committed before a failure stay removed, so report them either way
+	global.Logger.Info("Task pruning completed", "tasks", tasks, "decisions", decisions, "hardDelete", hard)
+	return err
+}
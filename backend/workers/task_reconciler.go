@@ -0,0 +1,41 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/JerryLinyx/FinGOAT/controllers"
+	"github.com/JerryLinyx/FinGOAT/global"
+)
+
+// RunTaskReconciler keeps active analysis tasks in sync with the Python service
+// until ctx is cancelled. With several replicas, one reconciles each tick.
+func RunTaskReconciler(ctx context.Context) {
+	interval := controllers.ReconcileInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	global.Logger.Info("Task reconciler started", "interval", interval.String())
+	for {
+		select {
+		case <-ctx.Done():
+			global.Logger.Info("Task reconciler stopped")
+			return
+		case <-ticker.C:
+			_ = runExclusive(ctx, "task_reconcile", interval, func() error {
+				runReconcileCycle(ctx)
+				return nil
+			})
+		}
+	}
+}
+
+func runReconcileCycle(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			global.Logger.Error("Task reconciler cycle panicked", "panic", r)
+		}
+	}()
+
+	controllers.ReconcileTasks(ctx)
+}